@@ -0,0 +1,131 @@
+package tdclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AuthMode selects credentials sent with requests.
+type AuthMode int
+
+const (
+	// AuthAuto sends token, if set, and session cookies
+	AuthAuto AuthMode = iota
+
+	// AuthToken sends token header only, cookies are neither sent nor stored
+	AuthToken
+
+	// AuthCookie sends session cookies only, like browser web session
+	AuthCookie
+)
+
+// WithAuthMode sets credentials sent with requests. Default is AuthAuto.
+func WithAuthMode(m AuthMode) Option {
+	return func(s *Session) error {
+		s.authMode = m
+		return nil
+	}
+}
+
+// WithCookieJar sets cookie jar for session cookies. Default is in-memory jar.
+func WithCookieJar(jar http.CookieJar) Option {
+	return func(s *Session) error {
+		s.jar = jar
+		return nil
+	}
+}
+
+// ReauthFunc gets new token when server rejects current one, and sets it with s.SetToken.
+// Rejected token is already removed from session and token store when ReauthFunc is called.
+type ReauthFunc func(ctx context.Context, s *Session) error
+
+// WithReauth sets callback for invalidated token. Request failed with 401 is repeated once after it.
+func WithReauth(fn ReauthFunc) Option {
+	return func(s *Session) error {
+		s.reauth = fn
+		return nil
+	}
+}
+
+type noReauthCtxKey struct{}
+
+// withoutReauth disables reauth for requests made with ctx.
+func withoutReauth(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noReauthCtxKey{}, true)
+}
+
+// reauthorize calls reauth callback once for concurrent requests failed with same token.
+func (s *Session) reauthorize(ctx context.Context, failedToken string) error {
+	s.reauthMu.Lock()
+	defer s.reauthMu.Unlock()
+
+	if s.getToken() != failedToken {
+		// already refreshed by concurrent request
+		return nil
+	}
+
+	s.logger.Warn("token rejected by server, reauth")
+	s.SetToken("")
+
+	return s.reauth(ctx, s)
+}
+
+func (s *Session) SetAuthMode(m AuthMode) {
+	s.authMode = m
+}
+
+// SetCookie stores cookies in session jar. Value is Cookie header, like "a=1; b=2".
+// Cookies are refreshed by Set-Cookie server responses.
+func (s *Session) SetCookie(v string) {
+	req := &http.Request{Header: http.Header{"Cookie": []string{v}}}
+	s.jar.SetCookies(&s.server, req.Cookies())
+}
+
+// authorize sets auth headers to request.
+func (s *Session) authorize(req *http.Request) {
+	s.authorizeHeader(req.URL, req.Header)
+}
+
+func (s *Session) authorizeHeader(u *url.URL, h http.Header) {
+	if token := s.getToken(); s.authMode != AuthCookie && token != "" {
+		h.Set("token", token)
+	}
+
+	if s.authMode != AuthToken {
+		cookies := s.jar.Cookies(u)
+		if len(cookies) == 0 {
+			return
+		}
+		values := make([]string, 0, len(cookies)+1)
+		if v := h.Get("Cookie"); v != "" {
+			values = append(values, v)
+		}
+		for _, c := range cookies {
+			values = append(values, c.String())
+		}
+		h.Set("Cookie", strings.Join(values, "; "))
+	}
+}
+
+// storeCookies saves Set-Cookie refreshes from server response.
+func (s *Session) storeCookies(u *url.URL, resp *http.Response) {
+	if s.authMode == AuthToken || resp == nil {
+		return
+	}
+	if cookies := resp.Cookies(); len(cookies) > 0 {
+		s.jar.SetCookies(u, cookies)
+	}
+}
+
+func (s *Session) hasCredentials() bool {
+	switch s.authMode {
+	case AuthToken:
+		return s.getToken() != ""
+	case AuthCookie:
+		return len(s.jar.Cookies(&s.server)) > 0
+	default:
+		return s.getToken() != "" || len(s.jar.Cookies(&s.server)) > 0
+	}
+}
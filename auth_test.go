@@ -0,0 +1,61 @@
+package tdclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCookieAuth(t *testing.T) {
+	var gotCookie, gotToken string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCookie = r.Header.Get("Cookie")
+		gotToken = r.Header.Get("token")
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "refreshed", Path: "/"})
+		_, _ = w.Write([]byte(`{"ok": true, "result": "pong"}`))
+	}))
+	defer srv.Close()
+
+	s, err := NewSession(srv.URL)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	s.SetToken("token")
+	s.SetCookie("session=initial; lang=ru")
+
+	t.Run("auto", func(t *testing.T) {
+		if err := s.Ping(); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if gotCookie != "session=initial; lang=ru" && gotCookie != "lang=ru; session=initial" {
+			t.Error("invalid cookie:", gotCookie)
+		}
+		if gotToken != "token" {
+			t.Error("invalid token:", gotToken)
+		}
+	})
+
+	t.Run("cookie refreshed", func(t *testing.T) {
+		s.SetAuthMode(AuthCookie)
+		if err := s.Ping(); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if gotCookie != "session=refreshed; lang=ru" && gotCookie != "lang=ru; session=refreshed" {
+			t.Error("invalid cookie:", gotCookie)
+		}
+		if gotToken != "" {
+			t.Error("token must not be sent:", gotToken)
+		}
+	})
+
+	t.Run("token only", func(t *testing.T) {
+		s.SetAuthMode(AuthToken)
+		if err := s.Ping(); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if gotCookie != "" {
+			t.Error("cookie must not be sent:", gotCookie)
+		}
+	})
+}
@@ -0,0 +1,208 @@
+package tdclient
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tada-team/tdproto"
+)
+
+const defaultBulkConcurrency = 4
+
+// BulkOp is one item of bulk operation. Name describes the change, it is reported in dry-run mode.
+type BulkOp struct {
+	Name string
+	Do   func(ctx context.Context) error
+}
+
+// BulkOptions configures Session.Bulk.
+type BulkOptions struct {
+	// Concurrency is number of parallel requests, default is 4.
+	Concurrency int
+	// RatePerSecond limits number of started operations per second, zero is unlimited.
+	RatePerSecond float64
+	// DryRun reports operations without doing them.
+	DryRun bool
+	// Progress is called after every finished operation, never concurrently.
+	Progress func(done, total int)
+}
+
+// BulkResult is outcome of one BulkOp.
+type BulkResult struct {
+	Index  int
+	Name   string
+	Err    error
+	DryRun bool
+}
+
+type BulkResults []BulkResult
+
+// Failed returns results with errors.
+func (r BulkResults) Failed() BulkResults {
+	var res BulkResults
+	for _, v := range r {
+		if v.Err != nil {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func (s *Session) Bulk(ops []BulkOp, opts BulkOptions) BulkResults {
+	return s.BulkContext(context.Background(), ops, opts)
+}
+
+// BulkContext runs operations with bounded concurrency and returns result for every operation, in same order.
+// Operations not started before context cancellation get context error.
+func (s *Session) BulkContext(ctx context.Context, ops []BulkOp, opts BulkOptions) BulkResults {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBulkConcurrency
+	}
+
+	var limiter *rateLimiter
+	if opts.RatePerSecond > 0 {
+		limiter = &rateLimiter{interval: time.Duration(float64(time.Second) / opts.RatePerSecond)}
+	}
+
+	results := make(BulkResults, len(ops))
+
+	var progressMu sync.Mutex
+	done := 0
+	finish := func(i int, err error) {
+		results[i] = BulkResult{
+			Index:  i,
+			Name:   ops[i].Name,
+			Err:    err,
+			DryRun: opts.DryRun,
+		}
+
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		done++
+		if opts.Progress != nil {
+			opts.Progress(done, len(ops))
+		}
+	}
+
+	jobs := make(chan int)
+	wg := new(sync.WaitGroup)
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if limiter != nil {
+					if err := limiter.wait(ctx); err != nil {
+						finish(i, err)
+						continue
+					}
+				}
+
+				if opts.DryRun {
+					s.logger.Info("bulk dry run", "op", ops[i].Name)
+					finish(i, nil)
+					continue
+				}
+
+				err := ops[i].Do(ctx)
+				if err != nil {
+					s.logger.Warn("bulk op fail", "op", ops[i].Name, "error", err)
+				}
+				finish(i, err)
+			}
+		}()
+	}
+
+	i := 0
+loop:
+	for ; i < len(ops); i++ {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for ; i < len(ops); i++ {
+		finish(i, ctx.Err())
+	}
+
+	return results
+}
+
+// rateLimiter spaces operation starts by interval.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	slot := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if d := time.Until(slot); d > 0 {
+		return sleepContext(ctx, d)
+	}
+	return ctx.Err()
+}
+
+func (t *TeamClient) DeleteMessageOp(chat tdproto.JID, msgId string) BulkOp {
+	return BulkOp{
+		Name: fmt.Sprintf("delete message %s in %s", msgId, chat),
+		Do: func(ctx context.Context) error {
+			_, err := t.DeleteMessageContext(ctx, chat, msgId)
+			return err
+		},
+	}
+}
+
+func (t *TeamClient) AddGroupMemberOp(group, contact tdproto.JID) BulkOp {
+	return BulkOp{
+		Name: fmt.Sprintf("add %s to group %s", contact, group),
+		Do: func(ctx context.Context) error {
+			_, err := t.AddGroupMemberContext(ctx, group, contact)
+			return err
+		},
+	}
+}
+
+func (t *TeamClient) DropGroupMemberOp(group, contact tdproto.JID) BulkOp {
+	return BulkOp{
+		Name: fmt.Sprintf("drop %s from group %s", contact, group),
+		Do: func(ctx context.Context) error {
+			return t.DropGroupMemberContext(ctx, group, contact)
+		},
+	}
+}
+
+func (t *TeamClient) CloseTaskOp(taskUid string) BulkOp {
+	return BulkOp{
+		Name: fmt.Sprintf("close task %s", taskUid),
+		Do: func(ctx context.Context) error {
+			_, err := t.CloseTaskContext(ctx, taskUid)
+			return err
+		},
+	}
+}
+
+func (t *TeamClient) SendPlaintextMessageOp(chat tdproto.JID, text string) BulkOp {
+	return BulkOp{
+		Name: fmt.Sprintf("send message to %s: %q", chat, text),
+		Do: func(ctx context.Context) error {
+			_, err := t.SendPlaintextMessageContext(ctx, chat, text)
+			return err
+		},
+	}
+}
@@ -0,0 +1,95 @@
+package tdclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tada-team/tdclient/tdclienttest"
+)
+
+func TestBulk(t *testing.T) {
+	srv, s, teamUid := newTestSession(t)
+
+	team, err := s.Team(teamUid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contacts, err := team.Contacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chat := contacts[1].Jid
+
+	var ops []BulkOp
+	for i := 0; i < 10; i++ {
+		ops = append(ops, team.SendPlaintextMessageOp(chat, fmt.Sprint(i)))
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		results := s.Bulk(ops, BulkOptions{DryRun: true})
+		for _, r := range results {
+			if !r.DryRun || r.Err != nil || r.Name == "" {
+				t.Error("invalid dry run result:", r)
+			}
+		}
+		if messages, _ := team.GetMessages(chat, nil); len(messages) != 0 {
+			t.Error("dry run sent messages:", len(messages))
+		}
+	})
+
+	t.Run("send", func(t *testing.T) {
+		srv.Fail(tdclienttest.Failure{Method: http.MethodPost, Path: "/api/v4/teams/", Status: http.StatusBadRequest})
+
+		var maxProgress int32
+		start := time.Now()
+		results := s.Bulk(ops, BulkOptions{
+			Concurrency:   3,
+			RatePerSecond: 100,
+			Progress: func(done, total int) {
+				if total != len(ops) {
+					t.Error("invalid total:", total)
+				}
+				atomic.StoreInt32(&maxProgress, int32(done))
+			},
+		})
+
+		if time.Since(start) < 80*time.Millisecond {
+			t.Error("rate limit ignored")
+		}
+		if int(maxProgress) != len(ops) {
+			t.Error("invalid progress:", maxProgress)
+		}
+		if failed := results.Failed(); len(failed) != 1 || !IsBadRequest(failed[0].Err) {
+			t.Fatal("one failure expected:", failed)
+		}
+		for i, r := range results {
+			if r.Index != i || r.Name != ops[i].Name {
+				t.Error("results order mismatch:", i, r)
+			}
+		}
+
+		messages, err := team.GetMessages(chat, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != len(ops)-1 {
+			t.Error("invalid messages number:", len(messages))
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		for _, r := range s.BulkContext(ctx, ops, BulkOptions{}) {
+			if !errors.Is(r.Err, context.Canceled) {
+				t.Error("want canceled, got:", r.Err)
+			}
+		}
+	})
+}
@@ -0,0 +1,434 @@
+package tdclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+// CassetteMode selects whether cassette records real traffic or serves recorded one.
+type CassetteMode int
+
+const (
+	// CassetteAuto replays existing cassette file or records new one.
+	CassetteAuto CassetteMode = iota
+	CassetteRecord
+	CassetteReplay
+)
+
+// maxMatchBodyLen is the largest request body compared as json, larger ones are compared by hash.
+const maxMatchBodyLen = 64 << 10
+
+// defaultCassetteIgnoredFields are generated by client on every call, so they are not used for matching.
+var defaultCassetteIgnoredFields = []string{
+	"message_id",
+}
+
+// Cassette records REST, upload and websocket traffic of session to file and replays it back.
+// Request is matched by method, path with query and normalized body: json keys are sorted,
+// redacted fields are masked (see WithRedactedFields) and IgnoredFields are dropped.
+// Every recorded exchange is replayed once. Websocket frames are replayed in recorded order,
+// received frame is released after client sent as many frames as it did before recording.
+// Secrets are scrubbed: request headers are not saved, response headers and bodies are redacted.
+type Cassette struct {
+	// IgnoredFields are json fields dropped from request bodies before matching, at any depth.
+	IgnoredFields []string
+
+	path     string
+	mode     CassetteMode
+	redactor *redactor
+
+	mu           sync.Mutex
+	interactions []*cassetteInteraction
+	used         map[*cassetteInteraction]bool
+}
+
+type cassetteInteraction struct {
+	Kind string `json:"kind"` // "http" or "ws"
+
+	// http
+	Method         string      `json:"method,omitempty"`
+	Path           string      `json:"path"`
+	RequestBody    string      `json:"request_body,omitempty"`
+	Status         int         `json:"status,omitempty"`
+	ResponseHeader http.Header `json:"response_header,omitempty"`
+	ResponseBody   string      `json:"response_body,omitempty"`
+	Base64         bool        `json:"base64,omitempty"`
+
+	// ws
+	Frames []cassetteFrame `json:"frames,omitempty"`
+}
+
+type cassetteFrame struct {
+	Send bool            `json:"send,omitempty"`
+	Data json.RawMessage `json:"data"`
+}
+
+type cassetteFile struct {
+	Interactions []*cassetteInteraction `json:"interactions"`
+}
+
+// NewCassette opens cassette file. In replay mode file must exist.
+func NewCassette(path string, mode CassetteMode) (*Cassette, error) {
+	c := &Cassette{
+		IgnoredFields: append([]string(nil), defaultCassetteIgnoredFields...),
+		path:          path,
+		mode:          mode,
+		redactor:      newRedactor(),
+		used:          make(map[*cassetteInteraction]bool),
+	}
+
+	b, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err) && mode != CassetteReplay:
+		c.mode = CassetteRecord
+		return c, nil
+	case err != nil:
+		return nil, errors.Wrap(err, "read cassette fail")
+	}
+
+	if c.mode == CassetteRecord {
+		return c, nil
+	}
+
+	f := new(cassetteFile)
+	if err := json.Unmarshal(b, f); err != nil {
+		return nil, errors.Wrapf(err, "invalid cassette: %s", path)
+	}
+
+	c.mode = CassetteReplay
+	c.interactions = f.Interactions
+	return c, nil
+}
+
+// WithCassette records session traffic to cassette or replays it, depending on cassette mode.
+// Session redacted fields are used for cassette.
+func WithCassette(c *Cassette) Option {
+	return func(s *Session) error {
+		if c == nil {
+			return errors.New("nil cassette")
+		}
+		c.redactor = s.redactor
+		s.cassette = c
+		return nil
+	}
+}
+
+// Mode returns CassetteRecord or CassetteReplay.
+func (c *Cassette) Mode() CassetteMode {
+	return c.mode
+}
+
+// Save writes recorded traffic to cassette file. Does nothing in replay mode.
+func (c *Cassette) Save() error {
+	if c.mode != CassetteRecord {
+		return nil
+	}
+
+	c.mu.Lock()
+	b, err := json.MarshalIndent(cassetteFile{Interactions: c.interactions}, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// middleware is the innermost session middleware: it sees requests as they go to network.
+func (c *Cassette) middleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		var body []byte
+		if req.Body != nil {
+			var err error
+			body, err = io.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		path := req.URL.RequestURI()
+		reqBody := c.normalizeBody(req, body)
+
+		if c.mode == CassetteReplay {
+			return c.replay(req, path, reqBody)
+		}
+
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+		it := &cassetteInteraction{
+			Kind:           "http",
+			Method:         req.Method,
+			Path:           path,
+			RequestBody:    reqBody,
+			Status:         resp.StatusCode,
+			ResponseHeader: c.redactor.headers(resp.Header),
+		}
+		if utf8.Valid(respBody) {
+			it.ResponseBody = string(c.redactJSON(respBody))
+		} else {
+			it.ResponseBody = base64.StdEncoding.EncodeToString(respBody)
+			it.Base64 = true
+		}
+
+		c.mu.Lock()
+		c.interactions = append(c.interactions, it)
+		c.mu.Unlock()
+
+		return resp, nil
+	})
+}
+
+func (c *Cassette) replay(req *http.Request, path, reqBody string) (*http.Response, error) {
+	c.mu.Lock()
+	var it *cassetteInteraction
+	for _, v := range c.interactions {
+		if !c.used[v] && v.Kind == "http" && v.Method == req.Method && v.Path == path && v.RequestBody == reqBody {
+			it = v
+			c.used[v] = true
+			break
+		}
+	}
+	c.mu.Unlock()
+
+	if it == nil {
+		return nil, errors.Errorf("cassette: no recorded response for %s %s", req.Method, path)
+	}
+
+	body := []byte(it.ResponseBody)
+	if it.Base64 {
+		var err error
+		body, err = base64.StdEncoding.DecodeString(it.ResponseBody)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid cassette body")
+		}
+	}
+
+	header := it.ResponseHeader.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        http.StatusText(it.Status),
+		StatusCode:    it.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// normalizeBody returns comparable form of request body: redacted json or hash of other content.
+func (c *Cassette) normalizeBody(req *http.Request, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	// multipart boundary is random
+	if mediatype, params, err := mime.ParseMediaType(req.Header.Get("Content-Type")); err == nil && strings.HasPrefix(mediatype, "multipart/") && params["boundary"] != "" {
+		body = bytes.ReplaceAll(body, []byte(params["boundary"]), []byte("boundary"))
+	}
+
+	if len(body) <= maxMatchBodyLen {
+		var v interface{}
+		if err := decodeJSONNumber(body, &v); err == nil {
+			c.redactor.walkRequest(req.URL.Path, v)
+			dropFields(v, c.IgnoredFields)
+			if b, err := json.Marshal(v); err == nil {
+				return string(b)
+			}
+		}
+	}
+
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func (c *Cassette) redactJSON(b []byte) []byte {
+	return c.redactor.redactJSON(b)
+}
+
+func dropFields(v interface{}, fields []string) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for _, f := range fields {
+			delete(t, f)
+		}
+		for _, child := range t {
+			dropFields(child, fields)
+		}
+	case []interface{}:
+		for _, child := range t {
+			dropFields(child, fields)
+		}
+	}
+}
+
+// wsConn opens websocket for path: dials in record mode, serves recorded frames in replay mode.
+func (c *Cassette) wsConn(path string, dial func() (*websocket.Conn, error)) (wsConn, error) {
+	if c.mode == CassetteReplay {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for _, v := range c.interactions {
+			if !c.used[v] && v.Kind == "ws" && v.Path == path {
+				c.used[v] = true
+				return newReplayWsConn(v.Frames), nil
+			}
+		}
+		return nil, errors.Errorf("cassette: no recorded websocket for %s", path)
+	}
+
+	conn, err := dial()
+	if err != nil {
+		return nil, err
+	}
+
+	it := &cassetteInteraction{
+		Kind: "ws",
+		Path: path,
+	}
+
+	c.mu.Lock()
+	c.interactions = append(c.interactions, it)
+	c.mu.Unlock()
+
+	return &recordingWsConn{Conn: conn, cassette: c, interaction: it}, nil
+}
+
+func (c *Cassette) addFrame(it *cassetteInteraction, send bool, data []byte) {
+	data = c.redactJSON(data)
+	if !json.Valid(data) {
+		data, _ = json.Marshal(string(data))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	it.Frames = append(it.Frames, cassetteFrame{Send: send, Data: data})
+}
+
+type recordingWsConn struct {
+	*websocket.Conn
+	cassette    *Cassette
+	interaction *cassetteInteraction
+}
+
+func (r *recordingWsConn) ReadMessage() (int, []byte, error) {
+	messageType, data, err := r.Conn.ReadMessage()
+	if err == nil {
+		r.cassette.addFrame(r.interaction, false, data)
+	}
+	return messageType, data, err
+}
+
+func (r *recordingWsConn) WriteMessage(messageType int, data []byte) error {
+	err := r.Conn.WriteMessage(messageType, data)
+	if err == nil {
+		r.cassette.addFrame(r.interaction, true, data)
+	}
+	return err
+}
+
+type replayWsConn struct {
+	frames []cassetteFrame
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	next   int // next frame to read
+	sent   int // frames sent by client
+	closed bool
+}
+
+func newReplayWsConn(frames []cassetteFrame) *replayWsConn {
+	r := &replayWsConn{frames: frames}
+	r.cond = sync.NewCond(&r.mu)
+	return r
+}
+
+func (r *replayWsConn) ReadMessage() (int, []byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for {
+		if r.closed {
+			return 0, nil, &websocket.CloseError{Code: websocket.CloseNormalClosure}
+		}
+
+		// skip frames sent by client, they are counted in WriteMessage
+		sendsBefore := 0
+		for i := 0; i < r.next; i++ {
+			if r.frames[i].Send {
+				sendsBefore++
+			}
+		}
+		for r.next < len(r.frames) && r.frames[r.next].Send {
+			r.next++
+			sendsBefore++
+		}
+
+		if r.next < len(r.frames) && r.sent >= sendsBefore {
+			data := r.frames[r.next].Data
+			r.next++
+			return websocket.BinaryMessage, data, nil
+		}
+
+		r.cond.Wait()
+	}
+}
+
+func (r *replayWsConn) WriteMessage(messageType int, data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return websocket.ErrCloseSent
+	}
+	r.sent++
+	r.cond.Broadcast()
+	return nil
+}
+
+func (r *replayWsConn) SetWriteDeadline(t time.Time) error { return nil }
+
+func (r *replayWsConn) CloseHandler() func(code int, text string) error {
+	return func(code int, text string) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.closed = true
+		r.cond.Broadcast()
+		return nil
+	}
+}
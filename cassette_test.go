@@ -0,0 +1,103 @@
+package tdclient
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tada-team/tdclient/tdclienttest"
+	"github.com/tada-team/tdproto"
+)
+
+func TestCassette(t *testing.T) {
+	srv := tdclienttest.NewServer()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	type result struct {
+		contacts []tdproto.Contact
+		message  tdproto.Message
+	}
+
+	run := func(mode CassetteMode) result {
+		c, err := NewCassette(path, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s, err := NewSession(srv.URL, WithCassette(c), WithLogger(NopLogger{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		auth, err := s.AuthBySmsGetToken(srv.Account.Phone, srv.Account.Code)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		s.SetToken(auth.Token)
+
+		teamUid := auth.Me.Teams[0].Uid
+
+		var res result
+		res.contacts, err = s.Contacts(teamUid)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+
+		res.message, err = s.SendPlaintextMessage(teamUid, res.contacts[1].Jid, "hello")
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+
+		ws, err := s.Ws(teamUid)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		ws.Ping()
+		if err := ws.WaitFor(new(tdproto.ServerConfirm)); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if err := ws.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := c.Save(); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	recorded := run(CassetteAuto)
+	srv.Close()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), srv.Account.Token) || strings.Contains(string(b), `"`+srv.Account.Code+`"`) {
+		t.Error("secrets are not scrubbed")
+	}
+
+	replayed := run(CassetteAuto)
+
+	if len(replayed.contacts) != len(recorded.contacts) || replayed.contacts[1].Jid != recorded.contacts[1].Jid {
+		t.Error("contacts mismatch")
+	}
+	if replayed.message.MessageId != recorded.message.MessageId || replayed.message.Gentime != recorded.message.Gentime {
+		t.Error("message mismatch:", replayed.message, recorded.message)
+	}
+
+	t.Run("unknown request", func(t *testing.T) {
+		c, err := NewCassette(path, CassetteReplay)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := NewSession(srv.URL, WithCassette(c), WithLogger(NopLogger{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Ping(); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+			t.Error("want cassette error, got:", err)
+		}
+	})
+}
@@ -0,0 +1,297 @@
+package tdclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tada-team/tdproto"
+	"github.com/tada-team/tdproto/tdapi"
+)
+
+func (t *TeamClient) AddTaskItem(task tdproto.JID, text string) (tdproto.TaskItem, error) {
+	return t.AddTaskItemContext(context.Background(), task, text)
+}
+
+// AddTaskItemContext appends item to task checklist. Text like "#123" links task number 123 as subtask.
+func (t *TeamClient) AddTaskItemContext(ctx context.Context, task tdproto.JID, text string) (tdproto.TaskItem, error) {
+	if !validTaskJid(task) {
+		return tdproto.TaskItem{}, InvalidTaskJid
+	}
+	if strings.TrimSpace(text) == "" {
+		return tdproto.TaskItem{}, InvalidTaskItem
+	}
+
+	req := map[string]interface{}{
+		"text": text,
+	}
+
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.TaskItem `json:"result"`
+	})
+
+	if err := t.session.doPost(ctx, t.taskPath(task)+"/items", req, resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+func (t *TeamClient) CheckTaskItem(task tdproto.JID, itemUid string) (tdproto.TaskItem, error) {
+	return t.CheckTaskItemContext(context.Background(), task, itemUid)
+}
+
+func (t *TeamClient) CheckTaskItemContext(ctx context.Context, task tdproto.JID, itemUid string) (tdproto.TaskItem, error) {
+	return t.updateTaskItem(ctx, task, itemUid, map[string]interface{}{"checked": true})
+}
+
+func (t *TeamClient) UncheckTaskItem(task tdproto.JID, itemUid string) (tdproto.TaskItem, error) {
+	return t.UncheckTaskItemContext(context.Background(), task, itemUid)
+}
+
+func (t *TeamClient) UncheckTaskItemContext(ctx context.Context, task tdproto.JID, itemUid string) (tdproto.TaskItem, error) {
+	return t.updateTaskItem(ctx, task, itemUid, map[string]interface{}{"checked": false})
+}
+
+func (t *TeamClient) ReorderTaskItems(task tdproto.JID, itemUids []string) ([]tdproto.TaskItem, error) {
+	return t.ReorderTaskItemsContext(context.Background(), task, itemUids)
+}
+
+// ReorderTaskItemsContext sets checklist order: item uids are listed in desired order.
+// Items missing in the list keep their relative order after listed ones. Returns whole checklist.
+func (t *TeamClient) ReorderTaskItemsContext(ctx context.Context, task tdproto.JID, itemUids []string) ([]tdproto.TaskItem, error) {
+	chat, err := t.GetTaskContext(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+
+	byUid := make(map[string]tdproto.TaskItem, len(chat.Items))
+	for _, item := range chat.Items {
+		byUid[item.Uid] = item
+	}
+
+	listed := make(map[string]bool, len(itemUids))
+	ordered := make([]tdproto.TaskItem, 0, len(chat.Items))
+	for _, uid := range itemUids {
+		item, ok := byUid[uid]
+		if !ok || listed[uid] {
+			return nil, InvalidTaskItem
+		}
+		listed[uid] = true
+		ordered = append(ordered, item)
+	}
+	for _, item := range chat.Items {
+		if !listed[item.Uid] {
+			ordered = append(ordered, item)
+		}
+	}
+
+	// Server shifts items with equal or greater sort ordering, like drag and drop in ui.
+	// Renumbering every item in desired order gives it 1..n whatever values were before.
+	for i, item := range ordered {
+		if _, err := t.updateTaskItem(ctx, task, item.Uid, map[string]interface{}{"sort_ordering": i + 1}); err != nil {
+			return nil, err
+		}
+	}
+
+	if chat, err = t.GetTaskContext(ctx, task); err != nil {
+		return nil, err
+	}
+
+	return chat.Items, nil
+}
+
+func (t *TeamClient) updateTaskItem(ctx context.Context, task tdproto.JID, itemUid string, req interface{}) (tdproto.TaskItem, error) {
+	if !validTaskJid(task) {
+		return tdproto.TaskItem{}, InvalidTaskJid
+	}
+	if itemUid == "" {
+		return tdproto.TaskItem{}, InvalidTaskItem
+	}
+
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.TaskItem `json:"result"`
+	})
+
+	// items are updated like tasks themselves, with POST
+	if err := t.session.doPost(ctx, t.taskPath(task)+"/items/"+itemUid, req, resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+func (t *TeamClient) CreateSubtask(parent tdproto.JID, req tdapi.Task) (tdproto.Chat, error) {
+	return t.CreateSubtaskContext(context.Background(), parent, req)
+}
+
+// CreateSubtaskContext creates task and links it to parent checklist.
+func (t *TeamClient) CreateSubtaskContext(ctx context.Context, parent tdproto.JID, req tdapi.Task) (tdproto.Chat, error) {
+	if !validTaskJid(parent) {
+		return tdproto.Chat{}, InvalidTaskJid
+	}
+
+	task, err := t.CreateTaskContext(ctx, req)
+	if err != nil {
+		return task, err
+	}
+
+	if _, err := t.linkSubtask(ctx, parent, task); err != nil {
+		return task, err
+	}
+
+	return task, nil
+}
+
+func (t *TeamClient) LinkSubtask(parent, task tdproto.JID) (tdproto.TaskItem, error) {
+	return t.LinkSubtaskContext(context.Background(), parent, task)
+}
+
+// LinkSubtaskContext adds existing task to parent checklist.
+func (t *TeamClient) LinkSubtaskContext(ctx context.Context, parent, task tdproto.JID) (tdproto.TaskItem, error) {
+	if !validTaskJid(parent) {
+		return tdproto.TaskItem{}, InvalidTaskJid
+	}
+
+	chat, err := t.GetTaskContext(ctx, task)
+	if err != nil {
+		return tdproto.TaskItem{}, err
+	}
+
+	return t.linkSubtask(ctx, parent, chat)
+}
+
+func (t *TeamClient) linkSubtask(ctx context.Context, parent tdproto.JID, task tdproto.Chat) (tdproto.TaskItem, error) {
+	return t.AddTaskItemContext(ctx, parent, fmt.Sprintf("#%d", task.Num))
+}
+
+func (t *TeamClient) Subtasks(parent tdproto.JID) ([]tdproto.Subtask, error) {
+	return t.SubtasksContext(context.Background(), parent)
+}
+
+// SubtasksContext returns tasks linked to parent checklist, in checklist order.
+func (t *TeamClient) SubtasksContext(ctx context.Context, parent tdproto.JID) ([]tdproto.Subtask, error) {
+	chat, err := t.GetTaskContext(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	var subtasks []tdproto.Subtask
+	for _, item := range chat.Items {
+		if item.Subtask != nil {
+			subtasks = append(subtasks, *item.Subtask)
+		}
+	}
+
+	return subtasks, nil
+}
+
+func (t *TeamClient) SendTaskComment(task tdproto.JID, text string) (tdproto.Message, error) {
+	return t.SendTaskCommentContext(context.Background(), task, text)
+}
+
+// SendTaskCommentContext posts plaintext message to task chat.
+func (t *TeamClient) SendTaskCommentContext(ctx context.Context, task tdproto.JID, text string) (tdproto.Message, error) {
+	if !validTaskJid(task) {
+		return tdproto.Message{}, InvalidTaskJid
+	}
+	return t.SendPlaintextMessageContext(ctx, task, text)
+}
+
+func (s *Session) AddTaskItem(teamUid string, task tdproto.JID, text string) (tdproto.TaskItem, error) {
+	return s.AddTaskItemContext(context.Background(), teamUid, task, text)
+}
+
+func (s *Session) AddTaskItemContext(ctx context.Context, teamUid string, task tdproto.JID, text string) (tdproto.TaskItem, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.TaskItem{}, err
+	}
+	return team.AddTaskItemContext(ctx, task, text)
+}
+
+func (s *Session) CheckTaskItem(teamUid string, task tdproto.JID, itemUid string) (tdproto.TaskItem, error) {
+	return s.CheckTaskItemContext(context.Background(), teamUid, task, itemUid)
+}
+
+func (s *Session) CheckTaskItemContext(ctx context.Context, teamUid string, task tdproto.JID, itemUid string) (tdproto.TaskItem, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.TaskItem{}, err
+	}
+	return team.CheckTaskItemContext(ctx, task, itemUid)
+}
+
+func (s *Session) UncheckTaskItem(teamUid string, task tdproto.JID, itemUid string) (tdproto.TaskItem, error) {
+	return s.UncheckTaskItemContext(context.Background(), teamUid, task, itemUid)
+}
+
+func (s *Session) UncheckTaskItemContext(ctx context.Context, teamUid string, task tdproto.JID, itemUid string) (tdproto.TaskItem, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.TaskItem{}, err
+	}
+	return team.UncheckTaskItemContext(ctx, task, itemUid)
+}
+
+func (s *Session) ReorderTaskItems(teamUid string, task tdproto.JID, itemUids []string) ([]tdproto.TaskItem, error) {
+	return s.ReorderTaskItemsContext(context.Background(), teamUid, task, itemUids)
+}
+
+func (s *Session) ReorderTaskItemsContext(ctx context.Context, teamUid string, task tdproto.JID, itemUids []string) ([]tdproto.TaskItem, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return nil, err
+	}
+	return team.ReorderTaskItemsContext(ctx, task, itemUids)
+}
+
+func (s *Session) CreateSubtask(teamUid string, parent tdproto.JID, req tdapi.Task) (tdproto.Chat, error) {
+	return s.CreateSubtaskContext(context.Background(), teamUid, parent, req)
+}
+
+func (s *Session) CreateSubtaskContext(ctx context.Context, teamUid string, parent tdproto.JID, req tdapi.Task) (tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Chat{}, err
+	}
+	return team.CreateSubtaskContext(ctx, parent, req)
+}
+
+func (s *Session) LinkSubtask(teamUid string, parent, task tdproto.JID) (tdproto.TaskItem, error) {
+	return s.LinkSubtaskContext(context.Background(), teamUid, parent, task)
+}
+
+func (s *Session) LinkSubtaskContext(ctx context.Context, teamUid string, parent, task tdproto.JID) (tdproto.TaskItem, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.TaskItem{}, err
+	}
+	return team.LinkSubtaskContext(ctx, parent, task)
+}
+
+func (s *Session) Subtasks(teamUid string, parent tdproto.JID) ([]tdproto.Subtask, error) {
+	return s.SubtasksContext(context.Background(), teamUid, parent)
+}
+
+func (s *Session) SubtasksContext(ctx context.Context, teamUid string, parent tdproto.JID) ([]tdproto.Subtask, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return nil, err
+	}
+	return team.SubtasksContext(ctx, parent)
+}
+
+func (s *Session) SendTaskComment(teamUid string, task tdproto.JID, text string) (tdproto.Message, error) {
+	return s.SendTaskCommentContext(context.Background(), teamUid, task, text)
+}
+
+func (s *Session) SendTaskCommentContext(ctx context.Context, teamUid string, task tdproto.JID, text string) (tdproto.Message, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Message{}, err
+	}
+	return team.SendTaskCommentContext(ctx, task, text)
+}
@@ -0,0 +1,226 @@
+package tdclient
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/tada-team/tdproto"
+	"github.com/tada-team/tdproto/tdapi"
+)
+
+// ContactUpdate is partial contact edit. Nil fields are not changed, pointer to empty string clears field.
+type ContactUpdate struct {
+	DisplayName  *string                      `json:"display_name,omitempty"`
+	Role         *string                      `json:"role,omitempty"`
+	ContactPhone *string                      `json:"contact_phone,omitempty"`
+	ContactEmail *string                      `json:"contact_email,omitempty"`
+	CustomFields *tdproto.ContactCustomFields `json:"custom_fields,omitempty"`
+}
+
+func (u ContactUpdate) validate() error {
+	if u.ContactPhone != nil && *u.ContactPhone != "" && !validPhone(*u.ContactPhone) {
+		return InvalidPhone
+	}
+	if u.ContactEmail != nil && *u.ContactEmail != "" && !validEmail(*u.ContactEmail) {
+		return InvalidEmail
+	}
+	return nil
+}
+
+// validPhone accepts international number: optional "+" and 5 to 15 digits, spaces, dashes and parentheses are ignored.
+func validPhone(phone string) bool {
+	phone = strings.TrimPrefix(phone, "+")
+	digits := 0
+	for _, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == ' ' || r == '-' || r == '(' || r == ')':
+		default:
+			return false
+		}
+	}
+	return digits >= 5 && digits <= 15
+}
+
+// validEmail accepts bare address, like "user@example.com", without display name.
+func validEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+func validContactJid(jid tdproto.JID) bool {
+	return jid.IsDirect() && jid.Valid()
+}
+
+func (t *TeamClient) GetContact(jid tdproto.JID) (tdproto.Contact, error) {
+	return t.GetContactContext(context.Background(), jid)
+}
+
+func (t *TeamClient) GetContactContext(ctx context.Context, jid tdproto.JID) (tdproto.Contact, error) {
+	if !validContactJid(jid) {
+		return tdproto.Contact{}, InvalidContactJid
+	}
+
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.Contact `json:"result"`
+	})
+
+	if err := t.session.doGet(ctx, t.contactPath(jid), nil, resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+func (t *TeamClient) UpdateContact(jid tdproto.JID, req ContactUpdate) (tdproto.Contact, error) {
+	return t.UpdateContactContext(context.Background(), jid, req)
+}
+
+// UpdateContactContext changes display name, role, contact phone or email and custom fields, like company and department.
+func (t *TeamClient) UpdateContactContext(ctx context.Context, jid tdproto.JID, req ContactUpdate) (tdproto.Contact, error) {
+	if err := req.validate(); err != nil {
+		return tdproto.Contact{}, err
+	}
+	return t.updateContact(ctx, jid, req)
+}
+
+func (t *TeamClient) ArchiveContact(jid tdproto.JID) (tdproto.Contact, error) {
+	return t.ArchiveContactContext(context.Background(), jid)
+}
+
+// ArchiveContactContext hides contact from contact list. Archived contact stays in team and can be unarchived.
+func (t *TeamClient) ArchiveContactContext(ctx context.Context, jid tdproto.JID) (tdproto.Contact, error) {
+	return t.updateContact(ctx, jid, map[string]interface{}{"is_archive": true})
+}
+
+func (t *TeamClient) UnarchiveContact(jid tdproto.JID) (tdproto.Contact, error) {
+	return t.UnarchiveContactContext(context.Background(), jid)
+}
+
+func (t *TeamClient) UnarchiveContactContext(ctx context.Context, jid tdproto.JID) (tdproto.Contact, error) {
+	return t.updateContact(ctx, jid, map[string]interface{}{"is_archive": false})
+}
+
+func (t *TeamClient) SetContactStatus(jid tdproto.JID, status tdproto.TeamStatus) (tdproto.Contact, error) {
+	return t.SetContactStatusContext(context.Background(), jid, status)
+}
+
+// SetContactStatusContext grants or revokes team admin rights: tdproto.TeamAdmin, tdproto.TeamMember or tdproto.TeamGuest.
+func (t *TeamClient) SetContactStatusContext(ctx context.Context, jid tdproto.JID, status tdproto.TeamStatus) (tdproto.Contact, error) {
+	switch status {
+	case tdproto.TeamAdmin, tdproto.TeamMember, tdproto.TeamGuest:
+	default:
+		return tdproto.Contact{}, InvalidTeamStatus
+	}
+	return t.updateContact(ctx, jid, map[string]interface{}{"status": status})
+}
+
+func (t *TeamClient) RemoveContact(jid tdproto.JID) error {
+	return t.RemoveContactContext(context.Background(), jid)
+}
+
+// RemoveContactContext removes contact from team.
+func (t *TeamClient) RemoveContactContext(ctx context.Context, jid tdproto.JID) error {
+	if !validContactJid(jid) {
+		return InvalidContactJid
+	}
+	resp := new(tdapi.Resp)
+	return t.session.doDelete(ctx, t.contactPath(jid), resp)
+}
+
+func (t *TeamClient) updateContact(ctx context.Context, jid tdproto.JID, req interface{}) (tdproto.Contact, error) {
+	if !validContactJid(jid) {
+		return tdproto.Contact{}, InvalidContactJid
+	}
+
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.Contact `json:"result"`
+	})
+
+	if err := t.session.doPost(ctx, t.contactPath(jid), req, resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+func (t *TeamClient) contactPath(jid tdproto.JID) string {
+	return fmt.Sprintf("/api/v4/teams/%s/contacts/%s", t.uid, jid)
+}
+
+func (s *Session) GetContact(teamUid string, jid tdproto.JID) (tdproto.Contact, error) {
+	return s.GetContactContext(context.Background(), teamUid, jid)
+}
+
+func (s *Session) GetContactContext(ctx context.Context, teamUid string, jid tdproto.JID) (tdproto.Contact, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Contact{}, err
+	}
+	return team.GetContactContext(ctx, jid)
+}
+
+func (s *Session) UpdateContact(teamUid string, jid tdproto.JID, req ContactUpdate) (tdproto.Contact, error) {
+	return s.UpdateContactContext(context.Background(), teamUid, jid, req)
+}
+
+func (s *Session) UpdateContactContext(ctx context.Context, teamUid string, jid tdproto.JID, req ContactUpdate) (tdproto.Contact, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Contact{}, err
+	}
+	return team.UpdateContactContext(ctx, jid, req)
+}
+
+func (s *Session) ArchiveContact(teamUid string, jid tdproto.JID) (tdproto.Contact, error) {
+	return s.ArchiveContactContext(context.Background(), teamUid, jid)
+}
+
+func (s *Session) ArchiveContactContext(ctx context.Context, teamUid string, jid tdproto.JID) (tdproto.Contact, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Contact{}, err
+	}
+	return team.ArchiveContactContext(ctx, jid)
+}
+
+func (s *Session) UnarchiveContact(teamUid string, jid tdproto.JID) (tdproto.Contact, error) {
+	return s.UnarchiveContactContext(context.Background(), teamUid, jid)
+}
+
+func (s *Session) UnarchiveContactContext(ctx context.Context, teamUid string, jid tdproto.JID) (tdproto.Contact, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Contact{}, err
+	}
+	return team.UnarchiveContactContext(ctx, jid)
+}
+
+func (s *Session) SetContactStatus(teamUid string, jid tdproto.JID, status tdproto.TeamStatus) (tdproto.Contact, error) {
+	return s.SetContactStatusContext(context.Background(), teamUid, jid, status)
+}
+
+func (s *Session) SetContactStatusContext(ctx context.Context, teamUid string, jid tdproto.JID, status tdproto.TeamStatus) (tdproto.Contact, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Contact{}, err
+	}
+	return team.SetContactStatusContext(ctx, jid, status)
+}
+
+func (s *Session) RemoveContact(teamUid string, jid tdproto.JID) error {
+	return s.RemoveContactContext(context.Background(), teamUid, jid)
+}
+
+func (s *Session) RemoveContactContext(ctx context.Context, teamUid string, jid tdproto.JID) error {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return err
+	}
+	return team.RemoveContactContext(ctx, jid)
+}
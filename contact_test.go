@@ -0,0 +1,97 @@
+package tdclient
+
+import (
+	"testing"
+
+	"github.com/tada-team/tdproto"
+)
+
+func TestContacts(t *testing.T) {
+	_, s, teamUid := newTestSession(t)
+	contact, err := s.AddContact(teamUid, "+7 (987) 000-00-00")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	t.Run("validation", func(t *testing.T) {
+		if _, err := s.AddContact(teamUid, "call me"); err != InvalidPhone {
+			t.Error("AddContact: want InvalidPhone, got:", err)
+		}
+		if _, err := s.GetContact(teamUid, tdproto.JID("g-"+contact.Jid.Uid())); err != InvalidContactJid {
+			t.Error("GetContact: want InvalidContactJid, got:", err)
+		}
+		if err := s.RemoveContact(teamUid, "d-123"); err != InvalidContactJid {
+			t.Error("RemoveContact: want InvalidContactJid, got:", err)
+		}
+		email := "John <john@example.com>"
+		if _, err := s.UpdateContact(teamUid, contact.Jid, ContactUpdate{ContactEmail: &email}); err != InvalidEmail {
+			t.Error("UpdateContact: want InvalidEmail, got:", err)
+		}
+		if _, err := s.SetContactStatus(teamUid, contact.Jid, tdproto.TeamOwner); err != InvalidTeamStatus {
+			t.Error("SetContactStatus: want InvalidTeamStatus, got:", err)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		name, role, email := "John Doe", "QA", "john@example.com"
+		updated, err := s.UpdateContact(teamUid, contact.Jid, ContactUpdate{
+			DisplayName:  &name,
+			Role:         &role,
+			ContactEmail: &email,
+			CustomFields: &tdproto.ContactCustomFields{Company: "ACME", Department: "R&D"},
+		})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if updated.DisplayName != name || updated.Role != role || updated.ContactEmail != email {
+			t.Error("invalid contact:", updated)
+		}
+
+		got, err := s.GetContact(teamUid, contact.Jid)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if got.CustomFields == nil || got.CustomFields.Company != "ACME" || got.CustomFields.Department != "R&D" {
+			t.Error("invalid custom fields:", got.CustomFields)
+		}
+		if got.ContactPhone != contact.ContactPhone {
+			t.Error("phone changed:", got.ContactPhone)
+		}
+	})
+
+	t.Run("archive", func(t *testing.T) {
+		c, err := s.ArchiveContact(teamUid, contact.Jid)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !c.IsArchive {
+			t.Error("not archived")
+		}
+		c, err = s.UnarchiveContact(teamUid, contact.Jid)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if c.IsArchive {
+			t.Error("still archived")
+		}
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		c, err := s.SetContactStatus(teamUid, contact.Jid, tdproto.TeamAdmin)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if c.TeamStatus != tdproto.TeamAdmin {
+			t.Error("invalid status:", c.TeamStatus)
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		if err := s.RemoveContact(teamUid, contact.Jid); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if _, err := s.GetContact(teamUid, contact.Jid); !IsNotFound(err) {
+			t.Error("want not found, got:", err)
+		}
+	})
+}
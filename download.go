@@ -0,0 +1,279 @@
+package tdclient
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tada-team/tdproto"
+)
+
+var (
+	SizeMismatch     = errors.New("downloaded size mismatched")
+	ChecksumMismatch = errors.New("downloaded checksum mismatched")
+)
+
+// DownloadOption configures single file download.
+type DownloadOption func(c *downloadConfig)
+
+type downloadConfig struct {
+	offset   int64
+	progress func(received, total int64)
+}
+
+// DownloadOffset continues download from offset: w receives bytes starting from offset.
+func DownloadOffset(v int64) DownloadOption {
+	return func(c *downloadConfig) {
+		c.offset = v
+	}
+}
+
+// DownloadProgress sets callback for bytes received, including offset. Total is -1 when size is unknown.
+func DownloadProgress(fn func(received, total int64)) DownloadOption {
+	return func(c *downloadConfig) {
+		c.progress = fn
+	}
+}
+
+func (s *Session) Download(upload tdproto.Upload, w io.Writer, opts ...DownloadOption) (int64, error) {
+	return s.DownloadContext(context.Background(), upload, w, opts...)
+}
+
+// DownloadContext writes upload content to w and returns number of bytes written.
+// Size is verified against upload.Size and Content-Length. Checksum is verified when server
+// sends Digest or Content-MD5 header and download is not started from offset.
+func (s *Session) DownloadContext(ctx context.Context, upload tdproto.Upload, w io.Writer, opts ...DownloadOption) (int64, error) {
+	var cfg downloadConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var prefix io.Reader
+	if cfg.offset == 0 {
+		prefix = strings.NewReader("")
+	}
+
+	return s.download(ctx, upload, w, prefix, cfg)
+}
+
+func (s *Session) DownloadToFile(upload tdproto.Upload, path string, opts ...DownloadOption) error {
+	return s.DownloadToFileContext(context.Background(), upload, path, opts...)
+}
+
+// DownloadToFileContext downloads upload to path. Data is written to path + ".part" first,
+// so interrupted download is resumed by next call with Range request.
+func (s *Session) DownloadToFileContext(ctx context.Context, upload tdproto.Upload, path string, opts ...DownloadOption) error {
+	var cfg downloadConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	partPath := path + ".part"
+	f, err := os.OpenFile(partPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	cfg.offset, err = f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.download(ctx, upload, f, io.NewSectionReader(f, 0, cfg.offset), cfg)
+	if errors.Is(err, SizeMismatch) || errors.Is(err, ChecksumMismatch) {
+		_ = f.Close()
+		_ = os.Remove(partPath)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(partPath, path)
+}
+
+// download copies upload content from cfg.offset to w. Prefix is already downloaded content,
+// used for checksum verification; nil prefix disables verification.
+func (s *Session) download(ctx context.Context, upload tdproto.Upload, w io.Writer, prefix io.Reader, cfg downloadConfig) (int64, error) {
+	if s.downloadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.downloadTimeout)
+		defer cancel()
+	}
+
+	u, err := s.server.Parse(upload.Url)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid upload url")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "new request fail")
+	}
+
+	// don't leak credentials to third-party storage
+	if s.sameOrigin(u) {
+		s.authorize(req)
+	}
+
+	if cfg.offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", cfg.offset))
+	}
+
+	s.logger.Debug("download", "url", u.String(), "range", req.Header.Get("Range"))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "client do fail")
+	}
+	defer resp.Body.Close()
+
+	if s.sameOrigin(u) {
+		s.storeCookies(u, resp)
+	}
+
+	total := int64(upload.Size)
+	if total <= 0 {
+		total = -1
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if cfg.offset > 0 {
+			// range ignored: skip already downloaded part
+			if _, err := io.CopyN(io.Discard, resp.Body, cfg.offset); err != nil {
+				return 0, errors.Wrap(err, "read body fail")
+			}
+		}
+		if total < 0 && resp.ContentLength >= 0 {
+			total = resp.ContentLength
+		} else if resp.ContentLength >= 0 && resp.ContentLength != total {
+			return 0, errors.Wrapf(SizeMismatch, "content length %d, upload size %d", resp.ContentLength, total)
+		}
+	case http.StatusPartialContent:
+		var start, end, size int64
+		if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &size); err != nil || start != cfg.offset {
+			return 0, errors.Errorf("invalid content range: %q", resp.Header.Get("Content-Range"))
+		}
+		if total < 0 {
+			total = size
+		} else if size != total {
+			return 0, errors.Wrapf(SizeMismatch, "content range size %d, upload size %d", size, total)
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		if cfg.offset > 0 && cfg.offset == total {
+			return 0, nil
+		}
+		fallthrough
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLen+1))
+		if err := checkResponse(req, resp, body); err != nil {
+			return 0, err
+		}
+		return 0, errors.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	verifier := newChecksumVerifier(resp.Header, resp.StatusCode == http.StatusOK)
+	if verifier != nil && prefix != nil {
+		if _, err := io.Copy(verifier, prefix); err != nil {
+			return 0, err
+		}
+	} else {
+		verifier = nil
+	}
+
+	var dst io.Writer = w
+	if verifier != nil {
+		dst = io.MultiWriter(w, verifier)
+	}
+	if cfg.progress != nil {
+		dst = &progressWriter{w: dst, received: cfg.offset, total: total, fn: cfg.progress}
+	}
+
+	n, err := io.Copy(dst, resp.Body)
+	if err != nil {
+		return n, errors.Wrap(err, "read body fail")
+	}
+
+	if total >= 0 && cfg.offset+n != total {
+		return n, errors.Wrapf(SizeMismatch, "got %d bytes, want %d", cfg.offset+n, total)
+	}
+
+	if verifier != nil && !verifier.valid() {
+		return n, ChecksumMismatch
+	}
+
+	return n, nil
+}
+
+func (s *Session) sameOrigin(u *url.URL) bool {
+	return u.Scheme == s.server.Scheme && u.Host == s.server.Host
+}
+
+type progressWriter struct {
+	w        io.Writer
+	received int64
+	total    int64
+	fn       func(received, total int64)
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if n > 0 {
+		w.received += int64(n)
+		w.fn(w.received, w.total)
+	}
+	return n, err
+}
+
+type checksumVerifier struct {
+	hash.Hash
+	want []byte
+}
+
+func (v *checksumVerifier) valid() bool {
+	return string(v.Sum(nil)) == string(v.want)
+}
+
+// newChecksumVerifier returns verifier for Digest (RFC 3230) or Content-MD5 headers, if any.
+// Content-MD5 describes response body, so it is used for full responses only.
+func newChecksumVerifier(h http.Header, full bool) *checksumVerifier {
+	for _, digest := range strings.Split(h.Get("Digest"), ",") {
+		i := strings.IndexByte(digest, '=')
+		if i < 0 {
+			continue
+		}
+		want, err := base64.StdEncoding.DecodeString(strings.TrimSpace(digest[i+1:]))
+		if err != nil {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(digest[:i])) {
+		case "sha-256":
+			return &checksumVerifier{Hash: sha256.New(), want: want}
+		case "md5":
+			return &checksumVerifier{Hash: md5.New(), want: want}
+		}
+	}
+
+	if full {
+		if want, err := base64.StdEncoding.DecodeString(h.Get("Content-MD5")); err == nil && len(want) > 0 {
+			return &checksumVerifier{Hash: md5.New(), want: want}
+		}
+	}
+
+	return nil
+}
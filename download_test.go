@@ -0,0 +1,100 @@
+package tdclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tada-team/tdproto"
+)
+
+func TestDownload(t *testing.T) {
+	content := []byte(strings.Repeat("downloaded content ", 1000))
+	sum := sha256.Sum256(content)
+	digest := "sha-256=" + base64.StdEncoding.EncodeToString(sum[:])
+
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("Digest", digest)
+		if r.URL.Path == "/broken" {
+			w.Header().Set("Digest", "sha-256="+base64.StdEncoding.EncodeToString([]byte("wrong")))
+		}
+		http.ServeContent(w, r, "file.txt", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	s, err := NewSession(srv.URL)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	s.SetToken("secret")
+
+	t.Run("writer", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		n, err := s.Download(tdproto.Upload{Url: "/files/file.txt"}, buf)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if n != int64(len(content)) || !bytes.Equal(buf.Bytes(), content) {
+			t.Error("content mismatched")
+		}
+	})
+
+	t.Run("resume file", func(t *testing.T) {
+		ranges = nil
+		path := filepath.Join(t.TempDir(), "file.txt")
+		if err := ioutil.WriteFile(path+".part", content[:100], 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		var received int64
+		err := s.DownloadToFile(tdproto.Upload{Url: srv.URL + "/files/file.txt"}, path, DownloadProgress(func(n, total int64) {
+			received = n
+		}))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b, content) {
+			t.Error("content mismatched")
+		}
+		if len(ranges) != 1 || ranges[0] != "bytes=100-" {
+			t.Error("invalid range requests:", ranges)
+		}
+		if received != int64(len(content)) {
+			t.Error("invalid progress:", received)
+		}
+		if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+			t.Error("part file not removed")
+		}
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "file.txt")
+		err := s.DownloadToFile(tdproto.Upload{Url: "/broken"}, path)
+		if !errors.Is(err, ChecksumMismatch) {
+			t.Fatalf("want checksum error, got: %v", err)
+		}
+		if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+			t.Error("broken part file not removed")
+		}
+	})
+}
@@ -1,5 +1,193 @@
 package tdclient
 
-import "github.com/pkg/errors"
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
 
-var InvalidTeamUid = errors.New("invalid team uid")
+	"github.com/pkg/errors"
+	"github.com/tada-team/tdproto/tdapi"
+)
+
+var (
+	InvalidTeamUid    = errors.New("invalid team uid")
+	InvalidTeamName   = errors.New("invalid team name")
+	InvalidContactJid = errors.New("invalid contact jid")
+	InvalidPhone      = errors.New("invalid phone")
+	InvalidEmail      = errors.New("invalid email")
+	InvalidTeamStatus = errors.New("invalid team status")
+
+	InvalidTaskJid         = errors.New("invalid task jid")
+	InvalidTaskStatus      = errors.New("invalid task status")
+	InvalidTaskDescription = errors.New("invalid task description")
+	InvalidTaskItem        = errors.New("invalid task item")
+
+	InvalidGroupJid    = errors.New("invalid group jid")
+	InvalidGroupName   = errors.New("invalid group name")
+	InvalidGroupStatus = errors.New("invalid group status")
+
+	InvalidChatJid = errors.New("invalid chat jid")
+	InvalidMessage = errors.New("empty message")
+)
+
+// APIError is unsuccessful server response: non-2xx status or "ok": false in body.
+type APIError struct {
+	StatusCode int
+	Method     string
+	Path       string
+
+	// Code, Message and Details are taken from tdapi error response, if any
+	Code    string
+	Message string
+	Details map[string]string
+
+	// Body is raw response for non-json answers, like html error pages
+	Body string
+
+	// RetryAfter is parsed Retry-After header, if any
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s: %d %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Code != "" {
+		fmt.Fprintf(&b, ": %s", e.Code)
+	}
+	if e.Message != "" && e.Message != e.Code {
+		fmt.Fprintf(&b, ": %s", e.Message)
+	}
+	for k, v := range e.Details {
+		fmt.Fprintf(&b, " [%s: %s]", k, v)
+	}
+	if e.Code == "" && e.Body != "" {
+		fmt.Fprintf(&b, ": %s", e.Body)
+	}
+	return b.String()
+}
+
+// IsNotFound reports whether err is 404 api error.
+func IsNotFound(err error) bool { return hasStatus(err, http.StatusNotFound) }
+
+// IsUnauthorized reports whether err is 401 api error, like invalid or expired token.
+func IsUnauthorized(err error) bool { return hasStatus(err, http.StatusUnauthorized) }
+
+// IsForbidden reports whether err is 403 api error.
+func IsForbidden(err error) bool { return hasStatus(err, http.StatusForbidden) }
+
+// IsRateLimited reports whether err is 429 api error.
+func IsRateLimited(err error) bool { return hasStatus(err, http.StatusTooManyRequests) }
+
+// IsBadRequest reports whether err is validation failure: 400 or 422 status. See APIError.Details for fields.
+func IsBadRequest(err error) bool {
+	return hasStatus(err, http.StatusBadRequest) || hasStatus(err, http.StatusUnprocessableEntity)
+}
+
+// IsClientError reports whether err is any 4xx api error.
+func IsClientError(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	status := apiErr.status()
+	return status >= 400 && status <= 499
+}
+
+// IsServerError reports whether err is 5xx api error.
+func IsServerError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.status() >= 500
+}
+
+func hasStatus(err error, code int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.status() == code
+}
+
+// status is http status used by IsXxx checks. Server may answer 2xx with "ok": false,
+// then status is taken from error code, like tdapi.AccessDenied. Unknown codes are treated as 400.
+func (e *APIError) status() int {
+	if e.StatusCode < 200 || e.StatusCode > 299 {
+		return e.StatusCode
+	}
+	if status := tdapi.Err(normalizeErrorCode(e.Code)).StatusCode(); status >= 400 {
+		return status
+	}
+	return http.StatusBadRequest
+}
+
+// normalizeErrorCode converts "AccessDenied" to "ACCESS_DENIED" form used by tdapi.Err.
+func normalizeErrorCode(code string) string {
+	var b strings.Builder
+	for i, r := range code {
+		if i > 0 && unicode.IsUpper(r) && unicode.IsLower(rune(code[i-1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+const maxErrorBodyLen = 512
+
+type errorResp struct {
+	Ok      *bool       `json:"ok"`
+	Error   interface{} `json:"error"`
+	Reason  string      `json:"reason"`
+	Details interface{} `json:"details"`
+}
+
+// checkResponse returns *APIError for unsuccessful response.
+func checkResponse(req *http.Request, resp *http.Response, body []byte) error {
+	var v errorResp
+	isJSON := JSON.Unmarshal(body, &v) == nil
+
+	failed := resp.StatusCode < 200 || resp.StatusCode > 299
+	if isJSON && v.Ok != nil && !*v.Ok {
+		failed = true
+	}
+	if !failed {
+		return nil
+	}
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	if !isJSON {
+		apiErr.Body = string(body)
+		if len(apiErr.Body) > maxErrorBodyLen {
+			apiErr.Body = apiErr.Body[:maxErrorBodyLen] + "..."
+		}
+		return apiErr
+	}
+
+	switch e := v.Error.(type) {
+	case string:
+		apiErr.Code = e
+	case map[string]interface{}:
+		if code, ok := e["code"]; ok {
+			apiErr.Code = fmt.Sprint(code)
+		}
+		if msg, ok := e["message"].(string); ok {
+			apiErr.Message = msg
+		}
+	}
+	if v.Reason != "" {
+		apiErr.Message = v.Reason
+	}
+
+	if details, ok := v.Details.(map[string]interface{}); ok && len(details) > 0 {
+		apiErr.Details = make(map[string]string, len(details))
+		for k, d := range details {
+			apiErr.Details[k] = fmt.Sprint(d)
+		}
+	}
+
+	return apiErr
+}
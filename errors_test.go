@@ -0,0 +1,72 @@
+package tdclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestCheckResponse(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v4/teams/xxx/contacts/", nil)
+
+	for _, tt := range []struct {
+		name   string
+		status int
+		body   string
+		check  func(error) bool
+		code   string
+		client bool
+	}{
+		{"ok", 200, `{"ok": true, "result": []}`, nil, "", false},
+		{"features", 200, `{"build": "1"}`, nil, "", false},
+		{"not found", 404, `{"ok": false, "error": "NotFound"}`, IsNotFound, "NotFound", true},
+		{"unauthorized", 401, `{"ok": false, "error": "InvalidToken"}`, IsUnauthorized, "InvalidToken", true},
+		{"rate limited", 429, `{"ok": false, "error": "TooManyRequests"}`, IsRateLimited, "TooManyRequests", true},
+		{"bad request", 400, `{"ok": false, "error": "BadRequest", "details": {"phone": "invalid"}}`, IsBadRequest, "BadRequest", true},
+		{"unprocessable", 422, `{"ok": false, "error": "InvalidData", "details": {"phone": "invalid"}}`, IsBadRequest, "InvalidData", true},
+		{"conflict", 409, `{"ok": false, "error": "Conflict"}`, IsClientError, "Conflict", true},
+		{"validation", 200, `{"ok": false, "error": "BadRequest", "details": {"phone": "invalid"}}`, IsBadRequest, "BadRequest", true},
+		{"invalid data in body", 200, `{"ok": false, "error": "INVALID_DATA", "details": {"phone": "invalid"}}`, IsBadRequest, "INVALID_DATA", true},
+		{"access denied in body", 200, `{"ok": false, "error": "ACCESS_DENIED"}`, IsForbidden, "ACCESS_DENIED", true},
+		{"not found in body", 200, `{"ok": false, "error": "NotFound"}`, IsNotFound, "NotFound", true},
+		{"server error in body", 200, `{"ok": false, "error": "INTERNAL_SERVER_ERROR"}`, IsServerError, "INTERNAL_SERVER_ERROR", false},
+		{"html", 502, `<html>Bad Gateway</html>`, IsServerError, "", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkResponse(req, &http.Response{StatusCode: tt.status}, []byte(tt.body))
+			if tt.check == nil && tt.code == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var apiErr *APIError
+			if !errors.As(errors.Wrap(err, "wrapped"), &apiErr) {
+				t.Fatalf("want *APIError, got: %v", err)
+			}
+			if tt.check != nil && !tt.check(err) {
+				t.Error("check failed:", err)
+			}
+			if IsClientError(err) != tt.client {
+				t.Error("IsClientError: want:", tt.client, "got:", !tt.client)
+			}
+			if apiErr.Code != tt.code {
+				t.Error("invalid code: want:", tt.code, "got:", apiErr.Code)
+			}
+			if apiErr.Method != http.MethodGet || apiErr.Path != "/api/v4/teams/xxx/contacts/" {
+				t.Error("invalid request info:", apiErr.Method, apiErr.Path)
+			}
+		})
+	}
+
+	t.Run("known error code in 2xx answer is not bad request", func(t *testing.T) {
+		for _, body := range []string{`{"ok": false, "error": "ACCESS_DENIED"}`, `{"ok": false, "error": "NotFound"}`} {
+			if err := checkResponse(req, &http.Response{StatusCode: 200}, []byte(body)); IsBadRequest(err) {
+				t.Error("classified as bad request:", err)
+			}
+		}
+	})
+}
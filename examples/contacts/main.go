@@ -18,6 +18,8 @@ func main() {
 		panic(err)
 	}
 
+	client.SetVerbose(settings.Verbose)
+
 	client.SetToken(settings.Token)
 
 	contacts, err := client.Contacts(settings.TeamUid)
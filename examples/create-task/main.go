@@ -37,6 +37,8 @@ func main() {
 		panic(err)
 	}
 
+	client.SetVerbose(settings.Verbose)
+
 	client.SetToken(settings.Token)
 
 	recipient := tdproto.JID(*assignee)
@@ -10,7 +10,6 @@ import (
 	"github.com/tada-team/tdclient"
 	"github.com/tada-team/tdclient/examples"
 	"github.com/tada-team/tdproto"
-	"github.com/tada-team/tdproto/tdapi"
 )
 
 func main() {
@@ -34,50 +33,54 @@ func main() {
 		panic(err)
 	}
 
+	client.SetVerbose(settings.Verbose)
+
 	client.SetToken(settings.Token)
 
-	var numProcessed int
+	team, err := client.Team(settings.TeamUid)
+	if err != nil {
+		panic(err)
+	}
 
 	chatUid := tdproto.JID(settings.Chat)
 
-	filter := new(tdapi.MessageFilter)
-	filter.Lang = "ru"
-	filter.Limit = 200
-	filter.Type = tdproto.MediatypeChange
-	filter.DateTo = tdproto.IsoDatetime(dt)
-
-	for {
-		messages, err := client.GetMessages(settings.TeamUid, chatUid, filter)
-		if err != nil {
-			panic(err)
-		}
-
-		if len(messages) == 0 {
-			break
+	it := team.MessagesIter(chatUid, tdclient.MessagesIterOptions{
+		Type:   tdproto.MediatypeChange,
+		Lang:   "ru",
+		DateTo: dt,
+	})
+
+	var ops []tdclient.BulkOp
+	var texts []string
+	for it.Next() {
+		m := it.Message()
+		if strings.HasPrefix(m.PushText, "Удалён участник:") {
+			ops = append(ops, team.DeleteMessageOp(chatUid, m.MessageId))
+			texts = append(texts, m.PushText)
 		}
+	}
 
-		filter.OldFrom = messages[len(messages)-1].MessageId
-		for _, m := range messages {
-			if !strings.HasPrefix(m.PushText, "Удалён участник:") {
-				continue
-			}
-
-			numProcessed++
-
-			if settings.DryRun {
-				fmt.Println("message will be deleted (dryrun):", numProcessed, m.PushText)
-				continue
-			}
-
-			if _, err := client.DeleteMessage(settings.TeamUid, chatUid, m.MessageId); err != nil {
-				panic(err)
-			}
-
-			fmt.Println("message deleted:", numProcessed, m.PushText)
-		}
+	if err := it.Err(); err != nil {
+		panic(err)
+	}
 
-		if len(messages) < filter.Limit {
-			break
+	results := client.Bulk(ops, tdclient.BulkOptions{
+		Concurrency:   4,
+		RatePerSecond: 20,
+		DryRun:        settings.DryRun,
+	})
+
+	for _, r := range results {
+		text := texts[r.Index]
+		switch {
+		case r.Err != nil:
+			fmt.Println("delete fail:", r.Index+1, text, r.Err)
+		case r.DryRun:
+			fmt.Println("message will be deleted (dryrun):", r.Index+1, text)
+		default:
+			fmt.Println("message deleted:", r.Index+1, text)
 		}
 	}
+
+	fmt.Println("processed:", len(results), "failed:", len(results.Failed()))
 }
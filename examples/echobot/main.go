@@ -8,7 +8,7 @@ import (
 	"github.com/tada-team/kozma"
 	"github.com/tada-team/tdclient"
 	"github.com/tada-team/tdclient/examples"
-	"github.com/tada-team/tdproto"
+	"github.com/tada-team/tdproto/tdapi"
 )
 
 func main() {
@@ -24,6 +24,8 @@ func main() {
 		panic(err)
 	}
 
+	client.SetVerbose(settings.Verbose)
+
 	client.SetToken(settings.Token)
 
 	websocketConnection, err := client.Ws(settings.TeamUid)
@@ -56,14 +58,9 @@ func main() {
 		log.Println("got:", message.PushText)
 
 		if *useReplyToField {
-			websocketConnection.SendEvent(tdproto.NewClientMessageUpdated(tdproto.ClientMessageUpdatedParams{
-				To:      message.Chat,
-				ReplyTo: message.MessageId,
-				Content: tdproto.MessageContent{
-					Type: tdproto.MediatypePlain,
-					Text: kozma.Say(),
-				},
-			}))
+			if _, err := client.SendMessage(settings.TeamUid, message.Chat, tdapi.Message{Text: kozma.Say()}, tdclient.MessageReplyTo(message.MessageId)); err != nil {
+				log.Println("reply fail:", err)
+			}
 		} else {
 			reply := fmt.Sprintf("> %s\n%s", message.PushText, kozma.Say())
 			websocketConnection.SendPlainMessage(message.Chat, reply)
@@ -25,6 +25,8 @@ func main() {
 		panic(err)
 	}
 
+	session.SetVerbose(settings.Verbose)
+
 	session.SetToken(settings.Token)
 
 	contacts, err := session.Contacts(settings.TeamUid)
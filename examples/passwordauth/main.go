@@ -17,6 +17,8 @@ func main() {
 		panic(err)
 	}
 
+	client.SetVerbose(settings.Verbose)
+
 	prompt := promptui.Prompt{Label: "Enter login"}
 	login, err := prompt.Run()
 	if err != nil {
@@ -23,6 +23,8 @@ func main() {
 		panic(err)
 	}
 
+	client.SetVerbose(settings.Verbose)
+
 	client.SetToken(settings.Token)
 
 	websocketConnection, err := client.Ws(settings.TeamUid)
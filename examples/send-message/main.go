@@ -23,6 +23,8 @@ func main() {
 		panic(err)
 	}
 
+	client.SetVerbose(settings.Verbose)
+
 	client.SetToken(settings.Token)
 
 	recipient := tdproto.JID(settings.Chat)
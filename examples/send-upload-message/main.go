@@ -30,6 +30,8 @@ func main() {
 		panic(err)
 	}
 
+	client.SetVerbose(settings.Verbose)
+
 	file, err := os.Open(*filePath)
 	if err != nil {
 		panic(err)
@@ -16,6 +16,8 @@ func main() {
 		panic(err)
 	}
 
+	client.SetVerbose(settings.Verbose)
+
 	features, err := client.Features()
 	if err != nil {
 		panic(err)
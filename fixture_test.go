@@ -0,0 +1,24 @@
+package tdclient
+
+import (
+	"testing"
+
+	"github.com/tada-team/tdclient/tdclienttest"
+)
+
+// newTestSession starts fake server and returns it with authorized session and uid of its team.
+// Server is closed when test is over.
+func newTestSession(t *testing.T) (*tdclienttest.Server, *Session, string) {
+	t.Helper()
+
+	srv := tdclienttest.NewServer()
+	t.Cleanup(srv.Close)
+
+	s, err := NewSession(srv.URL, WithLogger(NopLogger{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetToken(srv.Account.Token)
+
+	return srv, s, srv.Teams()[0]
+}
@@ -0,0 +1,263 @@
+package tdclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tada-team/tdproto"
+	"github.com/tada-team/tdproto/tdapi"
+)
+
+// GroupUpdate is partial group settings edit. Nil fields are not changed.
+type GroupUpdate struct {
+	DisplayName        *string `json:"display_name,omitempty"`
+	Description        *string `json:"description,omitempty"`
+	Public             *bool   `json:"public,omitempty"`
+	ReadonlyForMembers *bool   `json:"readonly_for_members,omitempty"`
+	DefaultForAll      *bool   `json:"default_for_all,omitempty"`
+}
+
+func validGroupJid(jid tdproto.JID) bool {
+	return jid.IsGroup() && jid.Valid()
+}
+
+func (t *TeamClient) GetGroup(group tdproto.JID) (tdproto.Chat, error) {
+	return t.GetGroupContext(context.Background(), group)
+}
+
+func (t *TeamClient) GetGroupContext(ctx context.Context, group tdproto.JID) (tdproto.Chat, error) {
+	if !validGroupJid(group) {
+		return tdproto.Chat{}, InvalidGroupJid
+	}
+
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.Chat `json:"result"`
+	})
+
+	if err := t.session.doGet(ctx, t.groupPath(group), nil, resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+func (t *TeamClient) UpdateGroup(group tdproto.JID, req GroupUpdate) (tdproto.Chat, error) {
+	return t.UpdateGroupContext(context.Background(), group, req)
+}
+
+// UpdateGroupContext changes group settings. Group admin rights are required.
+func (t *TeamClient) UpdateGroupContext(ctx context.Context, group tdproto.JID, req GroupUpdate) (tdproto.Chat, error) {
+	if !validGroupJid(group) {
+		return tdproto.Chat{}, InvalidGroupJid
+	}
+	if req.DisplayName != nil && strings.TrimSpace(*req.DisplayName) == "" {
+		return tdproto.Chat{}, InvalidGroupName
+	}
+
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.Chat `json:"result"`
+	})
+
+	if err := t.session.doPut(ctx, t.groupPath(group), req, resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+func (t *TeamClient) RenameGroup(group tdproto.JID, name string) (tdproto.Chat, error) {
+	return t.RenameGroupContext(context.Background(), group, name)
+}
+
+func (t *TeamClient) RenameGroupContext(ctx context.Context, group tdproto.JID, name string) (tdproto.Chat, error) {
+	return t.UpdateGroupContext(ctx, group, GroupUpdate{DisplayName: &name})
+}
+
+func (t *TeamClient) SetGroupDescription(group tdproto.JID, description string) (tdproto.Chat, error) {
+	return t.SetGroupDescriptionContext(context.Background(), group, description)
+}
+
+// SetGroupDescriptionContext changes group description. Empty description removes it.
+func (t *TeamClient) SetGroupDescriptionContext(ctx context.Context, group tdproto.JID, description string) (tdproto.Chat, error) {
+	return t.UpdateGroupContext(ctx, group, GroupUpdate{Description: &description})
+}
+
+func (t *TeamClient) SetGroupPublic(group tdproto.JID, public bool) (tdproto.Chat, error) {
+	return t.SetGroupPublicContext(context.Background(), group, public)
+}
+
+// SetGroupPublicContext makes group visible and joinable for all team members except guests.
+func (t *TeamClient) SetGroupPublicContext(ctx context.Context, group tdproto.JID, public bool) (tdproto.Chat, error) {
+	return t.UpdateGroupContext(ctx, group, GroupUpdate{Public: &public})
+}
+
+func (t *TeamClient) SetGroupReadonly(group tdproto.JID, readonly bool) (tdproto.Chat, error) {
+	return t.SetGroupReadonlyContext(context.Background(), group, readonly)
+}
+
+// SetGroupReadonlyContext allows only group admins to send messages, like channel.
+func (t *TeamClient) SetGroupReadonlyContext(ctx context.Context, group tdproto.JID, readonly bool) (tdproto.Chat, error) {
+	return t.UpdateGroupContext(ctx, group, GroupUpdate{ReadonlyForMembers: &readonly})
+}
+
+func (t *TeamClient) SetGroupMemberStatus(group, contact tdproto.JID, status tdproto.GroupStatus) (tdproto.GroupMembership, error) {
+	return t.SetGroupMemberStatusContext(context.Background(), group, contact, status)
+}
+
+// SetGroupMemberStatusContext promotes group member to tdproto.GroupAdmin or demotes to tdproto.GroupMember.
+func (t *TeamClient) SetGroupMemberStatusContext(ctx context.Context, group, contact tdproto.JID, status tdproto.GroupStatus) (tdproto.GroupMembership, error) {
+	if !validGroupJid(group) {
+		return tdproto.GroupMembership{}, InvalidGroupJid
+	}
+	if !validContactJid(contact) {
+		return tdproto.GroupMembership{}, InvalidContactJid
+	}
+	if status != tdproto.GroupAdmin && status != tdproto.GroupMember {
+		return tdproto.GroupMembership{}, InvalidGroupStatus
+	}
+
+	req := map[string]interface{}{
+		"status": status,
+	}
+
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.GroupMembership `json:"result"`
+	})
+
+	if err := t.session.doPut(ctx, fmt.Sprintf("%s/members/%s", t.groupPath(group), contact), req, resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+func (t *TeamClient) TransferGroupOwnership(group, contact tdproto.JID) error {
+	return t.TransferGroupOwnershipContext(context.Background(), group, contact)
+}
+
+// TransferGroupOwnershipContext promotes group member to admin, then demotes session account to member.
+// If demotion fails, both stay admins.
+func (t *TeamClient) TransferGroupOwnershipContext(ctx context.Context, group, contact tdproto.JID) error {
+	me, err := t.MeContext(ctx)
+	if err != nil {
+		return err
+	}
+	if me.Jid == contact {
+		return errors.New("can't transfer group to yourself")
+	}
+
+	if _, err := t.SetGroupMemberStatusContext(ctx, group, contact, tdproto.GroupAdmin); err != nil {
+		return errors.Wrap(err, "promote fail")
+	}
+
+	if _, err := t.SetGroupMemberStatusContext(ctx, group, me.Jid, tdproto.GroupMember); err != nil {
+		return errors.Wrap(err, "demote fail")
+	}
+
+	return nil
+}
+
+func (t *TeamClient) groupPath(group tdproto.JID) string {
+	return fmt.Sprintf("/api/v4/teams/%s/groups/%s", t.uid, group)
+}
+
+func (s *Session) GetGroup(teamUid string, group tdproto.JID) (tdproto.Chat, error) {
+	return s.GetGroupContext(context.Background(), teamUid, group)
+}
+
+func (s *Session) GetGroupContext(ctx context.Context, teamUid string, group tdproto.JID) (tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Chat{}, err
+	}
+	return team.GetGroupContext(ctx, group)
+}
+
+func (s *Session) UpdateGroup(teamUid string, group tdproto.JID, req GroupUpdate) (tdproto.Chat, error) {
+	return s.UpdateGroupContext(context.Background(), teamUid, group, req)
+}
+
+func (s *Session) UpdateGroupContext(ctx context.Context, teamUid string, group tdproto.JID, req GroupUpdate) (tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Chat{}, err
+	}
+	return team.UpdateGroupContext(ctx, group, req)
+}
+
+func (s *Session) RenameGroup(teamUid string, group tdproto.JID, name string) (tdproto.Chat, error) {
+	return s.RenameGroupContext(context.Background(), teamUid, group, name)
+}
+
+func (s *Session) RenameGroupContext(ctx context.Context, teamUid string, group tdproto.JID, name string) (tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Chat{}, err
+	}
+	return team.RenameGroupContext(ctx, group, name)
+}
+
+func (s *Session) SetGroupDescription(teamUid string, group tdproto.JID, description string) (tdproto.Chat, error) {
+	return s.SetGroupDescriptionContext(context.Background(), teamUid, group, description)
+}
+
+func (s *Session) SetGroupDescriptionContext(ctx context.Context, teamUid string, group tdproto.JID, description string) (tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Chat{}, err
+	}
+	return team.SetGroupDescriptionContext(ctx, group, description)
+}
+
+func (s *Session) SetGroupPublic(teamUid string, group tdproto.JID, public bool) (tdproto.Chat, error) {
+	return s.SetGroupPublicContext(context.Background(), teamUid, group, public)
+}
+
+func (s *Session) SetGroupPublicContext(ctx context.Context, teamUid string, group tdproto.JID, public bool) (tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Chat{}, err
+	}
+	return team.SetGroupPublicContext(ctx, group, public)
+}
+
+func (s *Session) SetGroupReadonly(teamUid string, group tdproto.JID, readonly bool) (tdproto.Chat, error) {
+	return s.SetGroupReadonlyContext(context.Background(), teamUid, group, readonly)
+}
+
+func (s *Session) SetGroupReadonlyContext(ctx context.Context, teamUid string, group tdproto.JID, readonly bool) (tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Chat{}, err
+	}
+	return team.SetGroupReadonlyContext(ctx, group, readonly)
+}
+
+func (s *Session) SetGroupMemberStatus(teamUid string, group, contact tdproto.JID, status tdproto.GroupStatus) (tdproto.GroupMembership, error) {
+	return s.SetGroupMemberStatusContext(context.Background(), teamUid, group, contact, status)
+}
+
+func (s *Session) SetGroupMemberStatusContext(ctx context.Context, teamUid string, group, contact tdproto.JID, status tdproto.GroupStatus) (tdproto.GroupMembership, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.GroupMembership{}, err
+	}
+	return team.SetGroupMemberStatusContext(ctx, group, contact, status)
+}
+
+func (s *Session) TransferGroupOwnership(teamUid string, group, contact tdproto.JID) error {
+	return s.TransferGroupOwnershipContext(context.Background(), teamUid, group, contact)
+}
+
+func (s *Session) TransferGroupOwnershipContext(ctx context.Context, teamUid string, group, contact tdproto.JID) error {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return err
+	}
+	return team.TransferGroupOwnershipContext(ctx, group, contact)
+}
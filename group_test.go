@@ -0,0 +1,100 @@
+package tdclient
+
+import (
+	"testing"
+
+	"github.com/tada-team/tdproto"
+	"github.com/tada-team/tdproto/tdapi"
+)
+
+func TestGroupAdmin(t *testing.T) {
+	_, s, teamUid := newTestSession(t)
+	me, err := s.Me(teamUid)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	contact, err := s.AddContact(teamUid, "+79870000000")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	group, err := s.CreateGroup(teamUid, tdapi.Group{DisplayName: "ops"})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if _, err := s.AddGroupMember(teamUid, group.Jid, contact.Jid); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	t.Run("validation", func(t *testing.T) {
+		if _, err := s.GetGroup(teamUid, contact.Jid); err != InvalidGroupJid {
+			t.Error("GetGroup: want InvalidGroupJid, got:", err)
+		}
+		if _, err := s.RenameGroup(teamUid, group.Jid, ""); err != InvalidGroupName {
+			t.Error("RenameGroup: want InvalidGroupName, got:", err)
+		}
+		if _, err := s.SetGroupMemberStatus(teamUid, group.Jid, contact.Jid, "owner"); err != InvalidGroupStatus {
+			t.Error("SetGroupMemberStatus: want InvalidGroupStatus, got:", err)
+		}
+	})
+
+	t.Run("settings", func(t *testing.T) {
+		if _, err := s.RenameGroup(teamUid, group.Jid, "ops team"); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if _, err := s.SetGroupDescription(teamUid, group.Jid, "on-call"); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if _, err := s.SetGroupPublic(teamUid, group.Jid, true); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if _, err := s.SetGroupReadonly(teamUid, group.Jid, true); err != nil {
+			t.Fatalf("%+v", err)
+		}
+
+		got, err := s.GetGroup(teamUid, group.Jid)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if got.DisplayName != "ops team" || got.Description != "on-call" || !got.Public || !got.ReadonlyForMembers {
+			t.Error("invalid group:", got)
+		}
+	})
+
+	t.Run("member status", func(t *testing.T) {
+		m, err := s.SetGroupMemberStatus(teamUid, group.Jid, contact.Jid, tdproto.GroupAdmin)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if m.Status != tdproto.GroupAdmin {
+			t.Error("invalid status:", m.Status)
+		}
+		if m, err = s.SetGroupMemberStatus(teamUid, group.Jid, contact.Jid, tdproto.GroupMember); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if m.Status != tdproto.GroupMember {
+			t.Error("invalid status:", m.Status)
+		}
+		if _, err := s.SetGroupMemberStatus(teamUid, group.Jid, me.Jid, tdproto.GroupMember); !IsForbidden(err) {
+			t.Error("last admin demoted:", err)
+		}
+	})
+
+	t.Run("transfer ownership", func(t *testing.T) {
+		if err := s.TransferGroupOwnership(teamUid, group.Jid, contact.Jid); err != nil {
+			t.Fatalf("%+v", err)
+		}
+
+		members, err := s.GroupMembers(teamUid, group.Jid)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		statuses := make(map[tdproto.JID]tdproto.GroupStatus)
+		for _, m := range members {
+			statuses[m.Jid] = m.Status
+		}
+		if statuses[contact.Jid] != tdproto.GroupAdmin || statuses[me.Jid] != tdproto.GroupMember {
+			t.Error("invalid statuses:", statuses)
+		}
+	})
+}
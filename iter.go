@@ -0,0 +1,355 @@
+package tdclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tada-team/tdproto"
+	"github.com/tada-team/tdproto/tdapi"
+)
+
+const defaultMessagesPageSize = 200
+
+// MessagesDirection is order of MessagesIter.
+type MessagesDirection int
+
+const (
+	// Backward goes from newest to oldest message.
+	Backward MessagesDirection = iota
+	// Forward goes from Cursor to newest message.
+	Forward
+)
+
+// MessagesIterOptions configures MessagesIter. Zero value iterates whole chat from newest message.
+type MessagesIterOptions struct {
+	Direction MessagesDirection
+	// Cursor is message id to continue after, usually MessagesIter.Cursor of previous scan.
+	// Required for Forward direction.
+	Cursor   string
+	DateFrom time.Time
+	DateTo   time.Time
+	Type     tdproto.Mediatype
+	Lang     string
+	PageSize int
+}
+
+// MessagesIter fetches chat messages page by page, on demand:
+//
+//	it := team.MessagesIter(chat, tdclient.MessagesIterOptions{})
+//	for it.Next() {
+//		m := it.Message()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type MessagesIter struct {
+	ctx  context.Context
+	team *TeamClient
+	chat tdproto.JID
+	opts MessagesIterOptions
+
+	page    []tdproto.Message
+	current tdproto.Message
+	cursor  string
+	last    bool
+	err     error
+}
+
+func (s *Session) MessagesIter(teamUid string, chat tdproto.JID, opts MessagesIterOptions) *MessagesIter {
+	return s.MessagesIterContext(context.Background(), teamUid, chat, opts)
+}
+
+// MessagesIterContext returns messages iterator. Context is used for every page request.
+func (s *Session) MessagesIterContext(ctx context.Context, teamUid string, chat tdproto.JID, opts MessagesIterOptions) *MessagesIter {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return &MessagesIter{err: err}
+	}
+	return team.MessagesIterContext(ctx, chat, opts)
+}
+
+func (t *TeamClient) MessagesIter(chat tdproto.JID, opts MessagesIterOptions) *MessagesIter {
+	return t.MessagesIterContext(context.Background(), chat, opts)
+}
+
+// MessagesIterContext returns messages iterator. Context is used for every page request.
+func (t *TeamClient) MessagesIterContext(ctx context.Context, chat tdproto.JID, opts MessagesIterOptions) *MessagesIter {
+	it := &MessagesIter{
+		ctx:    ctx,
+		team:   t,
+		chat:   chat,
+		opts:   opts,
+		cursor: opts.Cursor,
+	}
+	if it.opts.PageSize <= 0 {
+		it.opts.PageSize = defaultMessagesPageSize
+	}
+	if opts.Direction == Forward && opts.Cursor == "" {
+		it.err = errors.New("forward messages iteration needs cursor")
+	}
+	return it
+}
+
+// Next advances to next message, fetching next page if needed. Returns false at the end or on error.
+func (it *MessagesIter) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	if len(it.page) == 0 {
+		if it.last {
+			return false
+		}
+		if err := it.fetch(); err != nil {
+			it.err = err
+			return false
+		}
+		if len(it.page) == 0 {
+			return false
+		}
+	}
+
+	it.current = it.page[0]
+	it.page = it.page[1:]
+	it.cursor = it.current.MessageId
+	return true
+}
+
+func (it *MessagesIter) fetch() error {
+	f := new(tdapi.MessageFilter)
+	f.Limit = it.opts.PageSize
+	f.Type = it.opts.Type
+	f.Lang = it.opts.Lang
+	if !it.opts.DateFrom.IsZero() {
+		f.DateFrom = tdproto.IsoDatetime(it.opts.DateFrom)
+	}
+	if !it.opts.DateTo.IsZero() {
+		f.DateTo = tdproto.IsoDatetime(it.opts.DateTo)
+	}
+	if it.opts.Direction == Forward {
+		f.NewFrom = it.cursor
+	} else {
+		f.OldFrom = it.cursor
+	}
+
+	messages, err := it.team.GetMessagesContext(it.ctx, it.chat, f)
+	if err != nil {
+		return err
+	}
+
+	// server returns newest message first
+	if it.opts.Direction == Forward {
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
+	}
+
+	it.page = messages
+	it.last = len(messages) < f.Limit
+	return nil
+}
+
+// Message returns current message.
+func (it *MessagesIter) Message() tdproto.Message {
+	return it.current
+}
+
+// Cursor returns id of last returned message. Pass it to MessagesIterOptions.Cursor to resume scan.
+func (it *MessagesIter) Cursor() string {
+	return it.cursor
+}
+
+func (it *MessagesIter) Err() error {
+	return it.err
+}
+
+// ChatsIter fetches chats page by page, on demand. Filter offset is used as starting cursor.
+type ChatsIter struct {
+	ctx  context.Context
+	team *TeamClient
+	f    tdapi.ChatFilter
+
+	page    []tdproto.Chat
+	current tdproto.Chat
+	done    bool
+	err     error
+}
+
+func (s *Session) ChatsIter(teamUid string, f *tdapi.ChatFilter) *ChatsIter {
+	return s.ChatsIterContext(context.Background(), teamUid, f)
+}
+
+// ChatsIterContext returns chats iterator. Context is used for every page request.
+func (s *Session) ChatsIterContext(ctx context.Context, teamUid string, f *tdapi.ChatFilter) *ChatsIter {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return &ChatsIter{err: err}
+	}
+	return team.ChatsIterContext(ctx, f)
+}
+
+func (t *TeamClient) ChatsIter(f *tdapi.ChatFilter) *ChatsIter {
+	return t.ChatsIterContext(context.Background(), f)
+}
+
+// ChatsIterContext returns chats iterator. Context is used for every page request.
+func (t *TeamClient) ChatsIterContext(ctx context.Context, f *tdapi.ChatFilter) *ChatsIter {
+	it := &ChatsIter{
+		ctx:  ctx,
+		team: t,
+	}
+	if f != nil {
+		it.f = *f
+	}
+	if it.f.Limit == 0 {
+		it.f.Limit = 100
+	}
+	return it
+}
+
+// Next advances to next chat, fetching next page if needed. Returns false at the end or on error.
+func (it *ChatsIter) Next() bool {
+	if it.err != nil || it.done {
+		return false
+	}
+
+	if len(it.page) == 0 {
+		if err := it.fetch(); err != nil {
+			it.err = err
+			return false
+		}
+		if len(it.page) == 0 {
+			it.done = true
+			return false
+		}
+	}
+
+	it.current = it.page[0]
+	it.page = it.page[1:]
+	it.f.Offset++
+	return true
+}
+
+func (it *ChatsIter) fetch() error {
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.PaginatedChats `json:"result"`
+	})
+
+	if err := it.team.session.doGet(it.ctx, fmt.Sprintf("/api/v4/teams/%s/chats", it.team.uid), &it.f, resp); err != nil {
+		return err
+	}
+
+	it.page = resp.Result.Objects
+	return nil
+}
+
+// Chat returns current chat.
+func (it *ChatsIter) Chat() tdproto.Chat {
+	return it.current
+}
+
+// Cursor returns offset of next chat. Pass it as filter offset to resume scan.
+func (it *ChatsIter) Cursor() int {
+	return it.f.Offset
+}
+
+func (it *ChatsIter) Err() error {
+	return it.err
+}
+
+// ContactsIter walks team contacts. Contacts are fetched by one request, on first Next call.
+type ContactsIter struct {
+	ctx  context.Context
+	team *TeamClient
+
+	contacts []tdproto.Contact
+	fetched  bool
+	current  tdproto.Contact
+	cursor   tdproto.JID
+	err      error
+}
+
+func (s *Session) ContactsIter(teamUid string, cursor tdproto.JID) *ContactsIter {
+	return s.ContactsIterContext(context.Background(), teamUid, cursor)
+}
+
+// ContactsIterContext returns contacts iterator, starting after cursor contact, if any.
+func (s *Session) ContactsIterContext(ctx context.Context, teamUid string, cursor tdproto.JID) *ContactsIter {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return &ContactsIter{err: err}
+	}
+	return team.ContactsIterContext(ctx, cursor)
+}
+
+func (t *TeamClient) ContactsIter(cursor tdproto.JID) *ContactsIter {
+	return t.ContactsIterContext(context.Background(), cursor)
+}
+
+// ContactsIterContext returns contacts iterator, starting after cursor contact, if any.
+// Iteration fails if cursor contact is not in team anymore.
+func (t *TeamClient) ContactsIterContext(ctx context.Context, cursor tdproto.JID) *ContactsIter {
+	return &ContactsIter{
+		ctx:    ctx,
+		team:   t,
+		cursor: cursor,
+	}
+}
+
+// Next advances to next contact. Returns false at the end or on error.
+func (it *ContactsIter) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	if !it.fetched {
+		contacts, err := it.team.ContactsContext(it.ctx)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.fetched = true
+		it.contacts = contacts
+		if it.cursor != "" {
+			found := false
+			for i, c := range contacts {
+				if c.Jid == it.cursor {
+					it.contacts = contacts[i+1:]
+					found = true
+					break
+				}
+			}
+			if !found {
+				// starting from scratch would yield already seen contacts again
+				it.err = errors.Errorf("contacts iteration cursor not found: %s", it.cursor)
+				return false
+			}
+		}
+	}
+
+	if len(it.contacts) == 0 {
+		return false
+	}
+
+	it.current = it.contacts[0]
+	it.contacts = it.contacts[1:]
+	it.cursor = it.current.Jid
+	return true
+}
+
+// Contact returns current contact.
+func (it *ContactsIter) Contact() tdproto.Contact {
+	return it.current
+}
+
+// Cursor returns jid of last returned contact. Pass it to ContactsIter to resume scan.
+func (it *ContactsIter) Cursor() tdproto.JID {
+	return it.cursor
+}
+
+func (it *ContactsIter) Err() error {
+	return it.err
+}
@@ -0,0 +1,105 @@
+package tdclient
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tada-team/tdproto"
+	"github.com/tada-team/tdproto/tdapi"
+)
+
+func TestIterators(t *testing.T) {
+	_, s, teamUid := newTestSession(t)
+
+	team, err := s.Team(teamUid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contacts, err := team.Contacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chat := contacts[1].Jid
+
+	const total = 7
+	for i := 0; i < total; i++ {
+		if _, err := team.SendPlaintextMessage(chat, fmt.Sprint(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	texts := func(it *MessagesIter, limit int) (res string) {
+		for len(res) < limit && it.Next() {
+			res += it.Message().Content.Text
+		}
+		if err := it.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	t.Run("messages backward", func(t *testing.T) {
+		it := team.MessagesIter(chat, MessagesIterOptions{PageSize: 3})
+		if got := texts(it, 4); got != "6543" {
+			t.Fatal("invalid first part:", got)
+		}
+
+		it = team.MessagesIter(chat, MessagesIterOptions{PageSize: 3, Cursor: it.Cursor()})
+		if got := texts(it, total); got != "210" {
+			t.Fatal("invalid resumed part:", got)
+		}
+
+		forward := team.MessagesIter(chat, MessagesIterOptions{PageSize: 2, Cursor: it.Cursor(), Direction: Forward})
+		if got := texts(forward, total); got != "123456" {
+			t.Fatal("invalid forward scan:", got)
+		}
+	})
+
+	t.Run("forward needs cursor", func(t *testing.T) {
+		it := team.MessagesIter(chat, MessagesIterOptions{Direction: Forward})
+		if it.Next() || it.Err() == nil {
+			t.Error("error expected")
+		}
+	})
+
+	t.Run("chats", func(t *testing.T) {
+		it := team.ChatsIter(&tdapi.ChatFilter{ChatType: "direct", Paginator: tdapi.Paginator{Limit: 2}})
+		var jids []tdproto.JID
+		for it.Next() {
+			jids = append(jids, it.Chat().Jid)
+		}
+		if err := it.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if len(jids) != len(contacts) || it.Cursor() != len(contacts) {
+			t.Error("invalid chats number:", len(jids), it.Cursor())
+		}
+	})
+
+	t.Run("contacts", func(t *testing.T) {
+		it := team.ContactsIter("")
+		if !it.Next() {
+			t.Fatal(it.Err())
+		}
+		it = team.ContactsIter(it.Cursor())
+		n := 0
+		for it.Next() {
+			n++
+		}
+		if n != len(contacts)-1 {
+			t.Error("invalid resumed contacts number:", n)
+		}
+	})
+
+	t.Run("contacts unknown cursor", func(t *testing.T) {
+		it := team.ContactsIter(tdproto.JID(tdproto.ContactPrefix + "unknown"))
+		if it.Next() || it.Err() == nil {
+			t.Error("error expected")
+		}
+	})
+
+	if it := s.MessagesIter("team", chat, MessagesIterOptions{}); it.Next() || it.Err() != InvalidTeamUid {
+		t.Error("want InvalidTeamUid, got:", it.Err())
+	}
+}
@@ -2,21 +2,33 @@ package tdclient
 
 import (
 	"bytes"
+	"encoding/json"
+	"io"
 
 	jsoniter "github.com/json-iterator/go"
-
 	"github.com/pkg/errors"
 )
 
 var JSON = jsoniter.ConfigCompatibleWithStandardLibrary
 
-func debugJSON(v interface{}) string {
-	b := new(bytes.Buffer)
-	debugEncoder := JSON.NewEncoder(b)
-	debugEncoder.SetIndent("", "    ")
-	debugEncoder.SetEscapeHTML(false)
-	if err := debugEncoder.Encode(v); err != nil {
-		tdclientGlgLogger.Fatalln(errors.Wrap(err, "json marshall fail"))
+// debugJSON indents raw json for debug logging. Invalid json is returned as is.
+func debugJSON(b []byte) string {
+	buf := new(bytes.Buffer)
+	if err := json.Indent(buf, b, "", "    "); err != nil {
+		return string(b)
+	}
+	return buf.String()
+}
+
+// decodeJSONNumber unmarshals single json value keeping numbers as json.Number, so int64 ids survive re-encoding.
+func decodeJSONNumber(b []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(v); err != nil {
+		return err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return errors.New("trailing data after json value")
 	}
-	return b.String()
+	return nil
 }
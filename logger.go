@@ -0,0 +1,169 @@
+package tdclient
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/kpango/glg"
+)
+
+// Logger is structured logger: message with key-value pairs. *slog.Logger satisfies it.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// WithLogger sets session logger. Default logger writes warnings and errors to stdout,
+// level can be changed by TDCLIENT_LOG_LEVEL environment variable or SetVerbose.
+func WithLogger(l Logger) Option {
+	return func(s *Session) error {
+		s.logger = l
+		return nil
+	}
+}
+
+func (s *Session) SetLogger(l Logger) {
+	s.logger = l
+}
+
+// SetVerbose enables debug logging of all requests and responses.
+// Works for default and std loggers; configure level of own logger yourself.
+func (s *Session) SetVerbose(v bool) {
+	if l, ok := s.logger.(interface{ SetVerbose(bool) }); ok {
+		l.SetVerbose(v)
+	}
+}
+
+// NopLogger discards everything.
+type NopLogger struct{}
+
+func (NopLogger) Debug(msg string, args ...interface{}) {}
+func (NopLogger) Info(msg string, args ...interface{})  {}
+func (NopLogger) Warn(msg string, args ...interface{})  {}
+func (NopLogger) Error(msg string, args ...interface{}) {}
+
+// StdLogger writes to standard library logger. Debug messages are written in verbose mode only.
+type StdLogger struct {
+	l       *log.Logger
+	mu      sync.RWMutex
+	verbose bool
+}
+
+func NewStdLogger(l *log.Logger) *StdLogger {
+	return &StdLogger{l: l}
+}
+
+func (s *StdLogger) SetVerbose(v bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.verbose = v
+}
+
+func (s *StdLogger) Debug(msg string, args ...interface{}) {
+	s.mu.RLock()
+	verbose := s.verbose
+	s.mu.RUnlock()
+	if verbose {
+		s.l.Println("DEBUG", formatLogMessage(msg, args))
+	}
+}
+
+func (s *StdLogger) Info(msg string, args ...interface{}) {
+	s.l.Println("INFO", formatLogMessage(msg, args))
+}
+
+func (s *StdLogger) Warn(msg string, args ...interface{}) {
+	s.l.Println("WARN", formatLogMessage(msg, args))
+}
+
+func (s *StdLogger) Error(msg string, args ...interface{}) {
+	s.l.Println("ERROR", formatLogMessage(msg, args))
+}
+
+// glgLogger is default logger, one glg instance per session.
+type glgLogger struct {
+	glg *glg.Glg
+
+	// debug skips formatting of debug messages, which can be expensive
+	mu    sync.RWMutex
+	debug bool
+}
+
+func newGlgLogger() *glgLogger {
+	l := &glgLogger{glg: glg.New()}
+
+	logLevelEnv, found := os.LookupEnv("TDCLIENT_LOG_LEVEL")
+	if found {
+		level := glg.Atol(logLevelEnv)
+		l.glg.SetLevel(level)
+		l.debug = level == glg.DEBG
+	} else {
+		l.glg.SetLevel(glg.WARN)
+	}
+
+	return l
+}
+
+func (l *glgLogger) SetVerbose(v bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.debug = v
+	if v {
+		l.glg.SetLevel(glg.DEBG)
+	} else {
+		l.glg.SetLevel(glg.WARN)
+	}
+}
+
+func (l *glgLogger) Debug(msg string, args ...interface{}) {
+	l.mu.RLock()
+	debug := l.debug
+	l.mu.RUnlock()
+	if debug {
+		_ = l.glg.Debug(formatLogMessage(msg, args))
+	}
+}
+
+func (l *glgLogger) Info(msg string, args ...interface{}) {
+	_ = l.glg.Info(formatLogMessage(msg, args))
+}
+
+func (l *glgLogger) Warn(msg string, args ...interface{}) {
+	_ = l.glg.Warn(formatLogMessage(msg, args))
+}
+
+func (l *glgLogger) Error(msg string, args ...interface{}) {
+	_ = l.glg.Error(formatLogMessage(msg, args))
+}
+
+// formatLogMessage formats key-value pairs like slog text handler.
+func formatLogMessage(msg string, args []interface{}) string {
+	if len(args) == 0 {
+		return msg
+	}
+
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			fmt.Fprintf(&b, " !BADKEY=%v", args[i])
+			break
+		}
+		v := fmt.Sprint(args[i+1])
+		switch {
+		case strings.Contains(v, "\n"):
+			// multiline values, like indented json, are more readable as is
+			v = "\n" + v
+		case strings.ContainsAny(v, " \t\"="):
+			v = fmt.Sprintf("%q", v)
+		}
+		fmt.Fprintf(&b, " %v=%s", args[i], v)
+	}
+	return b.String()
+}
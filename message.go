@@ -0,0 +1,108 @@
+package tdclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tada-team/tdproto"
+	"github.com/tada-team/tdproto/tdapi"
+)
+
+// MessageOption configures message sent by SendMessage.
+type MessageOption func(r *messageRequest)
+
+// messageRequest is tdapi.Message with fields known by server but missing in tdapi.
+type messageRequest struct {
+	tdapi.Message
+	ReplyTo        string   `json:"reply_to,omitempty"`
+	LinkedMessages []string `json:"linked_messages,omitempty"`
+
+	edit string
+}
+
+// MessageEdit replaces text and flags of existing message instead of sending new one.
+// Only own messages can be edited.
+func MessageEdit(messageId string) MessageOption {
+	return func(r *messageRequest) {
+		r.edit = messageId
+	}
+}
+
+// MessageReplyTo quotes message from the same chat.
+func MessageReplyTo(messageId string) MessageOption {
+	return func(r *messageRequest) {
+		r.ReplyTo = messageId
+	}
+}
+
+// MessageForward attaches messages from any chat of the team. Server shows them with original sender and date.
+// Message text is optional for forwards.
+func MessageForward(messageIds ...string) MessageOption {
+	return func(r *messageRequest) {
+		r.LinkedMessages = append(r.LinkedMessages, messageIds...)
+	}
+}
+
+func (t *TeamClient) SendMessage(chat tdproto.JID, msg tdapi.Message, opts ...MessageOption) (tdproto.Message, error) {
+	return t.SendMessageContext(context.Background(), chat, msg, opts...)
+}
+
+// SendMessageContext sends message to chat, or edits existing one with MessageEdit option.
+// Type defaults to tdproto.MediatypePlain, use msg.Important to highlight message.
+// MessageUid of new message is generated if empty: set it to make repeated calls safe, like Outbox does.
+func (t *TeamClient) SendMessageContext(ctx context.Context, chat tdproto.JID, msg tdapi.Message, opts ...MessageOption) (tdproto.Message, error) {
+	if !chat.Valid() {
+		return tdproto.Message{}, InvalidChatJid
+	}
+
+	req := &messageRequest{Message: msg}
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	if strings.TrimSpace(req.Text) == "" && len(req.LinkedMessages) == 0 && len(req.Uploads) == 0 {
+		return tdproto.Message{}, InvalidMessage
+	}
+
+	if req.edit != "" {
+		req.MessageUid = req.edit
+		return t.postMessage(ctx, fmt.Sprintf("/api/v4/teams/%s/chats/%s/messages/%s", t.uid, chat, req.edit), req)
+	}
+
+	if req.MessageUid == "" {
+		req.MessageUid = uuid.New().String()
+	}
+	return t.postMessage(ctx, fmt.Sprintf("/api/v4/teams/%s/chats/%s/messages", t.uid, chat), req)
+}
+
+// postMessage sends new or edited message. Request is repeated safely: message uid is its idempotency key.
+func (t *TeamClient) postMessage(ctx context.Context, path string, req *messageRequest) (tdproto.Message, error) {
+	if req.Type == "" {
+		req.Type = tdproto.MediatypePlain
+	}
+
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.Message `json:"result"`
+	})
+
+	if err := t.session.doPost(WithIdempotencyKey(ctx, req.MessageUid), path, req, resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+func (s *Session) SendMessage(teamUid string, chat tdproto.JID, msg tdapi.Message, opts ...MessageOption) (tdproto.Message, error) {
+	return s.SendMessageContext(context.Background(), teamUid, chat, msg, opts...)
+}
+
+func (s *Session) SendMessageContext(ctx context.Context, teamUid string, chat tdproto.JID, msg tdapi.Message, opts ...MessageOption) (tdproto.Message, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Message{}, err
+	}
+	return team.SendMessageContext(ctx, chat, msg, opts...)
+}
@@ -0,0 +1,113 @@
+package tdclient
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/tada-team/tdproto/tdapi"
+)
+
+func TestSendMessage(t *testing.T) {
+	_, s, teamUid := newTestSession(t)
+	contacts, err := s.Contacts(teamUid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	me, err := s.Me(teamUid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, bob := contacts[1].Jid, contacts[2].Jid
+
+	original, err := s.SendMessage(teamUid, alice, tdapi.Message{Text: "hello"})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	t.Run("validation", func(t *testing.T) {
+		if _, err := s.SendMessage(teamUid, "", tdapi.Message{Text: "hi"}); err != InvalidChatJid {
+			t.Error("SendMessage: want InvalidChatJid, got:", err)
+		}
+		if _, err := s.SendMessage(teamUid, alice, tdapi.Message{Text: " "}); err != InvalidMessage {
+			t.Error("SendMessage: want InvalidMessage, got:", err)
+		}
+		if _, err := s.SendMessage(teamUid, bob, tdapi.Message{Text: "hi"}, MessageReplyTo(original.MessageId)); !IsBadRequest(err) {
+			t.Error("reply to other chat:", err)
+		}
+	})
+
+	t.Run("edit", func(t *testing.T) {
+		msg, err := s.SendMessage(teamUid, alice, tdapi.Message{Text: "hello, world"}, MessageEdit(original.MessageId))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if msg.MessageId != original.MessageId || msg.PushText != "hello, world" || msg.Edited == "" {
+			t.Error("invalid message:", msg)
+		}
+
+		if _, err := s.SendMessage(teamUid, alice, tdapi.Message{Text: "hi"}, MessageEdit("unknown")); !IsNotFound(err) {
+			t.Error("edit of unknown message: want not found, got:", err)
+		}
+	})
+
+	t.Run("client message uid", func(t *testing.T) {
+		uid := uuid.New().String()
+		msg, err := s.SendMessage(teamUid, alice, tdapi.Message{MessageUid: uid, Text: "queued"})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if msg.MessageId != uid || msg.Edited != "" {
+			t.Error("invalid message:", msg)
+		}
+
+		// repeated send returns the same message
+		again, err := s.SendMessage(teamUid, alice, tdapi.Message{MessageUid: uid, Text: "queued"})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if again.MessageId != uid {
+			t.Error("invalid message:", again)
+		}
+	})
+
+	t.Run("reply", func(t *testing.T) {
+		msg, err := s.SendMessage(teamUid, alice, tdapi.Message{Text: "ping"}, MessageReplyTo(original.MessageId))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if msg.ReplyTo == nil || msg.ReplyTo.MessageId != original.MessageId {
+			t.Error("invalid reply:", msg.ReplyTo)
+		}
+	})
+
+	t.Run("forward", func(t *testing.T) {
+		second, err := s.SendMessage(teamUid, alice, tdapi.Message{Text: "second"})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+
+		msg, err := s.SendMessage(teamUid, bob, tdapi.Message{}, MessageForward(original.MessageId, second.MessageId))
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if msg.Chat != bob || len(msg.LinkedMessages) != 2 {
+			t.Fatal("invalid forward:", msg)
+		}
+		if linked := msg.LinkedMessages[0]; linked.MessageId != original.MessageId || linked.From != me.Jid || linked.Chat != alice {
+			t.Error("invalid attribution:", linked)
+		}
+	})
+
+	t.Run("important", func(t *testing.T) {
+		req := tdapi.Message{Text: "release at 18:00"}
+		req.Important = true
+
+		msg, err := s.SendMessage(teamUid, alice, req)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !msg.Important {
+			t.Error("invalid flags:", msg)
+		}
+	})
+}
@@ -0,0 +1,43 @@
+package tdclient
+
+import "net/http"
+
+// Middleware wraps http transport of Session: REST calls and uploads pass through it.
+// Middleware sees every attempt, including retries, with auth headers already set.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is adapter to use function as http.RoundTripper.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// WithMiddleware adds middlewares to session. First middleware is outermost.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(s *Session) error {
+		s.middlewares = append(s.middlewares, mw...)
+		return nil
+	}
+}
+
+// HeaderMiddleware sets static headers to every request.
+func HeaderMiddleware(h http.Header) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			for k, v := range h {
+				req.Header[k] = v
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+func chainMiddlewares(rt http.RoundTripper, mw []Middleware) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	for i := len(mw) - 1; i >= 0; i-- {
+		rt = mw[i](rt)
+	}
+	return rt
+}
@@ -0,0 +1,239 @@
+package tdclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func pongHandler(w http.ResponseWriter, r *http.Request) {
+	_, _ = w.Write([]byte(`{"ok": true, "result": "pong"}`))
+}
+
+func TestNetworkOptions(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(pongHandler))
+	defer srv.Close()
+
+	caPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	ping := func(opts ...Option) error {
+		s, err := NewSession(srv.URL, append(opts, WithLogger(NopLogger{}))...)
+		if err != nil {
+			return err
+		}
+		return s.Ping()
+	}
+
+	t.Run("root ca", func(t *testing.T) {
+		if err := ping(); err == nil {
+			t.Error("unknown authority must fail")
+		}
+		if err := ping(WithRootCAs(caPem)); err != nil {
+			t.Errorf("%+v", err)
+		}
+	})
+
+	t.Run("pinning", func(t *testing.T) {
+		if err := ping(WithRootCAs(caPem), WithPinnedCertificates(CertificatePin(srv.Certificate()))); err != nil {
+			t.Errorf("%+v", err)
+		}
+
+		otherPin := base64.StdEncoding.EncodeToString(make([]byte, 32))
+		if err := ping(WithRootCAs(caPem), WithPinnedCertificates(otherPin)); err == nil || !strings.Contains(err.Error(), "pinned") {
+			t.Error("pin mismatch must fail, got:", err)
+		}
+
+		if _, err := NewSession(srv.URL, WithPinnedCertificates("invalid")); err == nil {
+			t.Error("invalid pin must fail")
+		}
+
+		insecure := &tls.Config{InsecureSkipVerify: true}
+		if err := ping(WithTLSConfig(insecure), WithPinnedCertificates(CertificatePin(srv.Certificate()))); err == nil {
+			t.Error("pinning without verification must fail")
+		}
+	})
+
+	t.Run("pinned certificate outside of verified chain", func(t *testing.T) {
+		caCert, caKey := newTestCA(t)
+		leaf := newTestServerCertificate(t, caCert, caKey)
+		// valid unpinned leaf also sends pinned certificate, like attacker with any CA-signed certificate would do
+		leaf.Certificate = append(leaf.Certificate, srv.Certificate().Raw)
+
+		evil := httptest.NewUnstartedServer(http.HandlerFunc(pongHandler))
+		evil.TLS = &tls.Config{Certificates: []tls.Certificate{leaf}}
+		evil.StartTLS()
+		defer evil.Close()
+
+		s, err := NewSession(evil.URL,
+			WithRootCAs(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw})),
+			WithPinnedCertificates(CertificatePin(srv.Certificate())),
+			WithLogger(NopLogger{}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Ping(); err == nil || !strings.Contains(err.Error(), "pinned") {
+			t.Error("extra pinned certificate must not pass, got:", err)
+		}
+	})
+
+	t.Run("client certificate", func(t *testing.T) {
+		mtls := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(r.TLS.PeerCertificates) == 0 {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			pongHandler(w, r)
+		}))
+		mtls.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+		mtls.StartTLS()
+		defer mtls.Close()
+
+		caPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: mtls.Certificate().Raw})
+
+		s, err := NewSession(mtls.URL, WithRootCAs(caPem), WithLogger(NopLogger{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Ping(); err == nil {
+			t.Error("request without client certificate must fail")
+		}
+
+		s, err = NewSession(mtls.URL, WithRootCAs(caPem), WithClientCertificate(newTestCertificate(t)), WithLogger(NopLogger{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Ping(); err != nil {
+			t.Errorf("%+v", err)
+		}
+	})
+
+	t.Run("proxy", func(t *testing.T) {
+		var proxied string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proxied = r.URL.String()
+			pongHandler(w, r)
+		}))
+		defer proxy.Close()
+
+		s, err := NewSession("http://tada.invalid", WithProxy(proxy.URL), WithLogger(NopLogger{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Ping(); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if proxied != "http://tada.invalid/api/v4/ping" {
+			t.Error("request not proxied:", proxied)
+		}
+		if s.wsDialer.Proxy == nil {
+			t.Error("websocket proxy not set")
+		}
+
+		s, err = NewSession("http://tada.invalid", WithLogger(NopLogger{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.transport.(*http.Transport).Proxy != nil {
+			t.Error("environment proxy must be opt-in")
+		}
+		s, err = NewSession("http://tada.invalid", WithProxyFromEnvironment(), WithLogger(NopLogger{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.transport.(*http.Transport).Proxy == nil {
+			t.Error("environment proxy not set")
+		}
+
+		if _, err := NewSession("http://tada.invalid", WithProxy("ftp://proxy")); err == nil {
+			t.Error("unsupported proxy scheme must fail")
+		}
+	})
+}
+
+func newTestCertificate(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "tdclient test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func newTestCA(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "tdclient test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, key
+}
+
+func newTestServerCertificate(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
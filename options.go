@@ -0,0 +1,140 @@
+package tdclient
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/cookiejar"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultRequestTimeout  = 10 * time.Second
+	defaultUploadTimeout   = 10 * time.Minute
+	defaultDownloadTimeout = 10 * time.Minute
+	defaultWsWriteTimeout  = 10 * time.Second
+	defaultWsWaitTimeout   = 10 * time.Second
+)
+
+// Option configures Session in NewSession.
+type Option func(s *Session) error
+
+// WithHTTPClient sets http client for REST calls and uploads.
+// Client is used as is: WithTransport and WithTLSConfig don't modify it.
+func WithHTTPClient(c *http.Client) Option {
+	return func(s *Session) error {
+		s.httpClient = c
+		return nil
+	}
+}
+
+// WithTransport sets round tripper for default http client.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(s *Session) error {
+		s.transport = rt
+		return nil
+	}
+}
+
+// WithWsDialer sets websocket dialer. Dialer is used as is: WithTLSConfig doesn't modify it.
+func WithWsDialer(d *websocket.Dialer) Option {
+	return func(s *Session) error {
+		s.wsDialer = d
+		return nil
+	}
+}
+
+// WithTLSConfig sets tls config for default transport and websocket dialer.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(s *Session) error {
+		s.tlsConfig = cfg
+		return nil
+	}
+}
+
+// WithRequestTimeout limits single REST call. Zero means no limit.
+func WithRequestTimeout(d time.Duration) Option {
+	return func(s *Session) error {
+		s.requestTimeout = d
+		return nil
+	}
+}
+
+// WithUploadTimeout limits single file upload. Zero means no limit.
+func WithUploadTimeout(d time.Duration) Option {
+	return func(s *Session) error {
+		s.uploadTimeout = d
+		return nil
+	}
+}
+
+// WithDownloadTimeout limits single file download. Zero means no limit.
+func WithDownloadTimeout(d time.Duration) Option {
+	return func(s *Session) error {
+		s.downloadTimeout = d
+		return nil
+	}
+}
+
+// WithWsWriteTimeout limits single websocket write. Zero means no limit.
+func WithWsWriteTimeout(d time.Duration) Option {
+	return func(s *Session) error {
+		s.wsWriteTimeout = d
+		return nil
+	}
+}
+
+// WithWsWaitTimeout limits WsSession.WaitFor and friends. Zero means no limit.
+func WithWsWaitTimeout(d time.Duration) Option {
+	return func(s *Session) error {
+		s.wsWaitTimeout = d
+		return nil
+	}
+}
+
+func (s *Session) setupClients() {
+	if s.jar == nil {
+		// cookiejar.New never fails without options
+		s.jar, _ = cookiejar.New(nil)
+	}
+
+	if s.tlsConfig == nil {
+		s.tlsConfig = &tls.Config{
+			// InsecureSkipVerify: true,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+	s.setupTLS()
+
+	if s.httpClient == nil {
+		if s.transport == nil {
+			s.transport = &http.Transport{
+				Proxy:             s.proxy,
+				TLSClientConfig:   s.tlsConfig,
+				ForceAttemptHTTP2: true,
+			}
+		}
+		s.httpClient = &http.Client{Transport: s.transport}
+	}
+
+	middlewares := s.middlewares
+	if s.cassette != nil {
+		middlewares = append(append([]Middleware(nil), middlewares...), s.cassette.middleware)
+	}
+
+	if len(middlewares) > 0 {
+		c := *s.httpClient
+		c.Transport = chainMiddlewares(c.Transport, middlewares)
+		s.httpClient = &c
+	}
+
+	if s.wsDialer == nil {
+		d := *websocket.DefaultDialer
+		d.TLSClientConfig = s.tlsConfig
+		if s.proxy != nil {
+			d.Proxy = s.proxy
+		}
+		s.wsDialer = &d
+	}
+}
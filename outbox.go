@@ -0,0 +1,373 @@
+package tdclient
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/tada-team/tdproto"
+)
+
+// OutboxState is delivery state of outbox item.
+type OutboxState string
+
+const (
+	OutboxPending OutboxState = "pending"
+	// OutboxSent is final state: message is delivered. Item is kept without text for OutboxSentRetention.
+	OutboxSent OutboxState = "sent"
+	// OutboxFailed is final state: server rejected message or attempts are exhausted.
+	OutboxFailed OutboxState = "failed"
+)
+
+const (
+	// DefaultOutboxSentRetention is how long delivered items are kept, so Item can report their state.
+	DefaultOutboxSentRetention = 24 * time.Hour
+	// DefaultOutboxFailedRetention is how long failed items are kept in outbox for inspection.
+	DefaultOutboxFailedRetention = 7 * 24 * time.Hour
+)
+
+// DefaultOutboxRetryPolicy retries delivery forever, with delay growing up to 5 minutes.
+var DefaultOutboxRetryPolicy = RetryPolicy{
+	MinBackoff: time.Second,
+	MaxBackoff: 5 * time.Minute,
+	Jitter:     0.2,
+}
+
+// OutboxItem is outgoing message saved in outbox directory.
+type OutboxItem struct {
+	MessageUid  string      `json:"message_uid"`
+	TeamUid     string      `json:"team_uid"`
+	Chat        tdproto.JID `json:"chat"`
+	Text        string      `json:"text"`
+	State       OutboxState `json:"state"`
+	Attempts    int         `json:"attempts"`
+	LastError   string      `json:"last_error,omitempty"`
+	Created     time.Time   `json:"created"`
+	Updated     time.Time   `json:"updated"`
+	NextAttempt time.Time   `json:"next_attempt"`
+}
+
+// OutboxOption configures Outbox.
+type OutboxOption func(o *Outbox)
+
+// OutboxRetryPolicy sets delivery backoff. Zero MaxAttempts means unlimited attempts.
+// Non-positive MinBackoff is replaced by default one, otherwise Run would hammer unavailable server.
+func OutboxRetryPolicy(p RetryPolicy) OutboxOption {
+	return func(o *Outbox) {
+		if p.MinBackoff <= 0 {
+			p.MinBackoff = DefaultOutboxRetryPolicy.MinBackoff
+		}
+		o.policy = p
+	}
+}
+
+// OutboxSentRetention sets how long delivered items are kept before removal. Zero keeps them until Remove.
+func OutboxSentRetention(d time.Duration) OutboxOption {
+	return func(o *Outbox) {
+		o.sentRetention = d
+	}
+}
+
+// OutboxFailedRetention sets how long failed items are kept before removal. Zero keeps them until Remove.
+func OutboxFailedRetention(d time.Duration) OutboxOption {
+	return func(o *Outbox) {
+		o.failedRetention = d
+	}
+}
+
+// OutboxOnUpdate sets callback called after every item state change.
+func OutboxOnUpdate(fn func(item OutboxItem)) OutboxOption {
+	return func(o *Outbox) {
+		o.onUpdate = fn
+	}
+}
+
+// Outbox persists outgoing messages to directory and delivers them when server is reachable.
+// Every item is a file named by its MessageUid. Server ignores repeated message with same uid,
+// so retry of send which actually succeeded doesn't make duplicate.
+// Delivered items are removed after OutboxSentRetention, failed ones after OutboxFailedRetention.
+type Outbox struct {
+	session         *Session
+	dir             string
+	policy          RetryPolicy
+	sentRetention   time.Duration
+	failedRetention time.Duration
+	onUpdate        func(item OutboxItem)
+
+	mu   sync.Mutex
+	wake chan struct{}
+}
+
+// NewOutbox opens outbox directory, creating it if needed. Items left from previous run are delivered by Run.
+func NewOutbox(s *Session, dir string, opts ...OutboxOption) (*Outbox, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "create outbox dir fail")
+	}
+
+	o := &Outbox{
+		session:         s,
+		dir:             dir,
+		policy:          DefaultOutboxRetryPolicy,
+		sentRetention:   DefaultOutboxSentRetention,
+		failedRetention: DefaultOutboxFailedRetention,
+		wake:            make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o, nil
+}
+
+// SendPlaintextMessage saves message to outbox and returns pending item. Delivery is done by Run or Flush.
+func (o *Outbox) SendPlaintextMessage(teamUid string, chat tdproto.JID, text string) (OutboxItem, error) {
+	if !tdproto.ValidUid(teamUid) {
+		return OutboxItem{}, InvalidTeamUid
+	}
+
+	now := time.Now()
+	item := OutboxItem{
+		MessageUid:  uuid.New().String(),
+		TeamUid:     teamUid,
+		Chat:        chat,
+		Text:        text,
+		State:       OutboxPending,
+		Created:     now,
+		Updated:     now,
+		NextAttempt: now,
+	}
+
+	o.mu.Lock()
+	err := o.save(item)
+	o.mu.Unlock()
+	if err != nil {
+		return item, err
+	}
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+
+	return item, nil
+}
+
+// Item returns outbox item by message uid. Error satisfies os.IsNotExist for unknown and removed items,
+// including delivered ones after OutboxSentRetention.
+func (o *Outbox) Item(messageUid string) (OutboxItem, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.load(o.path(messageUid))
+}
+
+// Items returns all outbox items, oldest first.
+func (o *Outbox) Items() ([]OutboxItem, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	paths, err := filepath.Glob(filepath.Join(o.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]OutboxItem, 0, len(paths))
+	for _, path := range paths {
+		item, err := o.load(path)
+		if err != nil {
+			o.session.logger.Warn("outbox item skipped", "path", path, "error", err)
+			continue
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Created.Before(items[j].Created) })
+	return items, nil
+}
+
+// Remove deletes item from outbox. Pending item will not be delivered.
+func (o *Outbox) Remove(messageUid string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := os.Remove(o.path(messageUid)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Run delivers pending items until context is done. Returns context error.
+func (o *Outbox) Run(ctx context.Context) error {
+	for {
+		next, err := o.deliver(ctx, false)
+		if err != nil {
+			o.session.logger.Warn("outbox delivery fail", "error", err)
+		}
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			timeout = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-o.wake:
+		case <-timeout:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// Flush tries to deliver all pending items once, ignoring backoff.
+func (o *Outbox) Flush(ctx context.Context) error {
+	_, err := o.deliver(ctx, true)
+	return err
+}
+
+// deliver sends due items and returns time of next due item, zero if there are no pending items.
+func (o *Outbox) deliver(ctx context.Context, force bool) (time.Time, error) {
+	items, err := o.Items()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var next time.Time
+	for _, item := range items {
+		if o.expired(item) {
+			if err := o.Remove(item.MessageUid); err != nil {
+				o.session.logger.Warn("outbox remove fail", "message_uid", item.MessageUid, "error", err)
+			}
+			continue
+		}
+		if item.State != OutboxPending {
+			continue
+		}
+
+		if !force && item.NextAttempt.After(time.Now()) {
+			if next.IsZero() || item.NextAttempt.Before(next) {
+				next = item.NextAttempt
+			}
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			return next, err
+		}
+
+		item = o.attempt(ctx, item)
+		if item.State == OutboxPending && (next.IsZero() || item.NextAttempt.Before(next)) {
+			next = item.NextAttempt
+		}
+	}
+
+	return next, nil
+}
+
+func (o *Outbox) attempt(ctx context.Context, item OutboxItem) OutboxItem {
+	item.Attempts++
+	item.Updated = time.Now()
+
+	team, err := o.session.Team(item.TeamUid)
+	if err == nil {
+		_, err = team.sendPlaintextMessage(ctx, item.Chat, item.Text, item.MessageUid)
+	}
+
+	switch {
+	case err == nil:
+		item.State = OutboxSent
+		item.LastError = ""
+	case permanentError(err) || (o.policy.MaxAttempts > 0 && item.Attempts >= o.policy.MaxAttempts):
+		item.State = OutboxFailed
+		item.LastError = err.Error()
+	default:
+		item.LastError = err.Error()
+		item.NextAttempt = time.Now().Add(o.policy.delay(item.Attempts))
+	}
+
+	saved := item
+	if saved.State == OutboxSent {
+		// text is not needed anymore, keep only small marker
+		saved.Text = ""
+	}
+
+	o.mu.Lock()
+	// item removed while sending
+	if _, statErr := os.Stat(o.path(item.MessageUid)); statErr == nil {
+		if saveErr := o.save(saved); saveErr != nil {
+			o.session.logger.Warn("outbox save fail", "message_uid", item.MessageUid, "error", saveErr)
+		}
+	}
+	o.mu.Unlock()
+
+	if o.onUpdate != nil {
+		o.onUpdate(item)
+	}
+
+	return item
+}
+
+// expired reports whether final item outlived its retention.
+func (o *Outbox) expired(item OutboxItem) bool {
+	var retention time.Duration
+	switch item.State {
+	case OutboxSent:
+		retention = o.sentRetention
+	case OutboxFailed:
+		retention = o.failedRetention
+	}
+	return retention > 0 && time.Since(item.Updated) > retention
+}
+
+// permanentError reports whether server rejected request, so it makes no sense to repeat it.
+func permanentError(err error) bool {
+	if err == InvalidTeamUid {
+		return true
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusRequestTimeout:
+		return false
+	}
+	return apiErr.StatusCode >= 400 && apiErr.StatusCode < 500 && apiErr.StatusCode != http.StatusTooManyRequests
+}
+
+func (o *Outbox) path(messageUid string) string {
+	return filepath.Join(o.dir, filepath.Base(messageUid)+".json")
+}
+
+func (o *Outbox) load(path string) (OutboxItem, error) {
+	var item OutboxItem
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return item, err
+	}
+	if err := JSON.Unmarshal(b, &item); err != nil {
+		return item, errors.Wrapf(err, "invalid outbox item: %s", strings.TrimSuffix(filepath.Base(path), ".json"))
+	}
+	return item, nil
+}
+
+func (o *Outbox) save(item OutboxItem) error {
+	b, err := JSON.Marshal(item)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(o.path(item.MessageUid), b)
+}
@@ -0,0 +1,130 @@
+package tdclient
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/tada-team/tdclient/tdclienttest"
+)
+
+func TestOutbox(t *testing.T) {
+	srv, s, teamUid := newTestSession(t)
+	contacts, err := s.Contacts(teamUid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chat := contacts[1].Jid
+
+	dir := t.TempDir()
+	updates := make(chan OutboxItem, 10)
+	outbox, err := NewOutbox(s, dir,
+		OutboxRetryPolicy(RetryPolicy{MinBackoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond}),
+		OutboxOnUpdate(func(item OutboxItem) { updates <- item }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// server is unavailable for two attempts
+	srv.Fail(tdclienttest.Failure{Method: http.MethodPost, Path: "/api/v4/teams/", Status: http.StatusServiceUnavailable, Times: 2})
+
+	item, err := outbox.SendPlaintextMessage(teamUid, chat, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.State != OutboxPending {
+		t.Fatal("invalid state:", item.State)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go outbox.Run(ctx)
+
+	for item.State == OutboxPending {
+		select {
+		case item = <-updates:
+		case <-ctx.Done():
+			t.Fatal("not delivered")
+		}
+	}
+	if item.State != OutboxSent || item.Attempts != 3 {
+		t.Fatal("invalid item:", item)
+	}
+
+	messages, err := s.GetMessages(teamUid, chat, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 || messages[0].MessageId != item.MessageUid {
+		t.Fatal("invalid messages:", messages)
+	}
+	if sent, err := outbox.Item(item.MessageUid); err != nil || sent.State != OutboxSent || sent.Text != "" {
+		t.Error("delivered item must be kept as marker:", sent, err)
+	}
+	if _, err := outbox.Item("unknown"); !os.IsNotExist(err) {
+		t.Error("want not exist, got:", err)
+	}
+
+	var failed OutboxItem
+	t.Run("rejected", func(t *testing.T) {
+		item, err := outbox.SendPlaintextMessage(teamUid, "d-unknown", "hello")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for item.State == OutboxPending {
+			select {
+			case item = <-updates:
+			case <-ctx.Done():
+				t.Fatal("not processed")
+			}
+		}
+		if item.State != OutboxFailed || item.LastError == "" {
+			t.Fatal("invalid item:", item)
+		}
+		failed = item
+	})
+
+	t.Run("persisted", func(t *testing.T) {
+		reopened, err := NewOutbox(s, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items, err := reopened.Items()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) != 2 || items[0].State != OutboxSent || items[1].MessageUid != failed.MessageUid || items[1].State != OutboxFailed {
+			t.Fatal("invalid items:", items)
+		}
+	})
+
+	t.Run("retention", func(t *testing.T) {
+		reopened, err := NewOutbox(s, dir, OutboxSentRetention(time.Nanosecond), OutboxFailedRetention(time.Nanosecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := reopened.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		items, err := reopened.Items()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items) != 0 {
+			t.Fatal("final items must be removed:", items)
+		}
+	})
+
+	t.Run("zero backoff", func(t *testing.T) {
+		reopened, err := NewOutbox(s, dir, OutboxRetryPolicy(RetryPolicy{MaxAttempts: 3}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reopened.policy.MinBackoff <= 0 {
+			t.Error("zero backoff must be replaced:", reopened.policy)
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package tdclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const redactedValue = "***"
+
+// defaultRedactedFields are masked in logged json at any depth and in logged headers.
+var defaultRedactedFields = []string{
+	"password",
+	"token",
+	"cookie",
+	"set-cookie",
+	"authorization",
+	"secret",
+}
+
+// defaultRedactedRequestFields are masked only in request body of given api path: these names are too
+// common to hide everywhere, like "code" that is also error code in server answers.
+var defaultRedactedRequestFields = map[string][]string{
+	"/api/v4/auth/sms/get-token": {"code"},
+}
+
+// WithRedactedFields adds fields masked in debug logs. Plain name, like "phone", matches field
+// at any depth; dotted path, like "result.me.contact_phone", matches from document root.
+// Arrays are transparent for paths.
+func WithRedactedFields(fields ...string) Option {
+	return func(s *Session) error {
+		s.redactor.add(fields...)
+		return nil
+	}
+}
+
+type redactor struct {
+	fields   map[string]bool
+	paths    map[string]bool
+	requests map[string]map[string]bool
+}
+
+func newRedactor() *redactor {
+	r := &redactor{
+		fields:   make(map[string]bool),
+		paths:    make(map[string]bool),
+		requests: make(map[string]map[string]bool),
+	}
+	r.add(defaultRedactedFields...)
+	for urlPath, fields := range defaultRedactedRequestFields {
+		r.requests[urlPath] = make(map[string]bool)
+		for _, f := range fields {
+			r.requests[urlPath][f] = true
+		}
+	}
+	return r
+}
+
+func (r *redactor) add(fields ...string) {
+	for _, f := range fields {
+		f = strings.ToLower(f)
+		if strings.Contains(f, ".") {
+			r.paths[f] = true
+		} else {
+			r.fields[f] = true
+		}
+	}
+}
+
+// json returns lazy log value: redaction and formatting happen only if value is actually logged.
+func (r *redactor) json(b []byte) redactedJSON {
+	return redactedJSON{r: r, b: b}
+}
+
+// request is like json, but also masks fields specific to request body of given url path.
+func (r *redactor) request(urlPath string, b []byte) redactedJSON {
+	return redactedJSON{r: r, b: b, urlPath: urlPath}
+}
+
+func (r *redactor) redactJSON(b []byte) []byte {
+	return r.redactRequestJSON("", b)
+}
+
+func (r *redactor) redactRequestJSON(urlPath string, b []byte) []byte {
+	var v interface{}
+	if err := decodeJSONNumber(b, &v); err != nil {
+		return b
+	}
+	r.walkRequest(urlPath, v)
+	res, err := json.Marshal(v)
+	if err != nil {
+		return b
+	}
+	return res
+}
+
+// walkRequest masks v in place. Fields of url path are matched from document root, like dotted paths.
+func (r *redactor) walkRequest(urlPath string, v interface{}) {
+	r.walk(v, "", r.requests[urlPath])
+}
+
+func (r *redactor) walk(v interface{}, path string, requestPaths map[string]bool) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			childPath := strings.ToLower(k)
+			if path != "" {
+				childPath = path + "." + childPath
+			}
+			if r.fields[strings.ToLower(k)] || r.paths[childPath] || requestPaths[childPath] {
+				t[k] = redactedValue
+				continue
+			}
+			r.walk(child, childPath, requestPaths)
+		}
+	case []interface{}:
+		for _, child := range t {
+			r.walk(child, path, requestPaths)
+		}
+	}
+}
+
+func (r *redactor) headers(h http.Header) http.Header {
+	res := make(http.Header, len(h))
+	for k, v := range h {
+		if r.fields[strings.ToLower(k)] {
+			res[k] = []string{redactedValue}
+		} else {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+type redactedJSON struct {
+	r       *redactor
+	b       []byte
+	urlPath string
+}
+
+func (v redactedJSON) String() string {
+	return debugJSON(v.r.redactRequestJSON(v.urlPath, v.b))
+}
+
+// MarshalJSON embeds redacted document into json logs, like slog.JSONHandler output. Non-json data is logged as string.
+func (v redactedJSON) MarshalJSON() ([]byte, error) {
+	b := v.r.redactRequestJSON(v.urlPath, v.b)
+	if json.Valid(b) {
+		return b, nil
+	}
+	return json.Marshal(string(b))
+}
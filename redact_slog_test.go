@@ -0,0 +1,36 @@
+//go:build go1.21
+// +build go1.21
+
+package tdclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestRedactedJSONSlog(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	r := newRedactor()
+	logger.Debug("request",
+		"body", r.json([]byte(`{"username": "user", "password": "p@ssw0rd"}`)),
+		"raw", r.json([]byte("not json")),
+	)
+
+	var got struct {
+		Body map[string]string `json:"body"`
+		Raw  string            `json:"raw"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err, buf.String())
+	}
+	if got.Body["username"] != "user" || got.Body["password"] != "***" {
+		t.Error("invalid body:", buf.String())
+	}
+	if got.Raw != "not json" {
+		t.Error("invalid raw:", buf.String())
+	}
+}
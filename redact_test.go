@@ -0,0 +1,62 @@
+package tdclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	r := newRedactor()
+	r.add("result.me.contact_phone")
+
+	in := `{
+		"username": "user",
+		"password": "p@ssw0rd",
+		"result": {
+			"token": "secret-token",
+			"me": {"contact_phone": "+70001234567", "display_name": "User"},
+			"teams": [{"me": {"contact_phone": "+70001234567"}}]
+		},
+		"details": [{"code": "InvalidPhone"}]
+	}`
+
+	var got, want interface{}
+	if err := json.Unmarshal(r.redactJSON([]byte(in)), &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(`{
+		"username": "user",
+		"password": "***",
+		"result": {
+			"token": "***",
+			"me": {"contact_phone": "***", "display_name": "User"},
+			"teams": [{"me": {"contact_phone": "+70001234567"}}]
+		},
+		"details": [{"code": "InvalidPhone"}]
+	}`), &want); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("invalid redaction: %v", got)
+	}
+
+	smsAuth := `{"phone": "+70001234567", "code": "1234"}`
+	if b := string(r.redactRequestJSON("/api/v4/auth/sms/get-token", []byte(smsAuth))); b != `{"code":"***","phone":"+70001234567"}` {
+		t.Error("sms code must be masked:", b)
+	}
+	if b := string(r.redactRequestJSON("/api/v4/teams", []byte(smsAuth))); b != `{"code":"1234","phone":"+70001234567"}` {
+		t.Error("code must be masked only in sms auth request:", b)
+	}
+
+	if b := r.redactJSON([]byte("not json")); string(b) != "not json" {
+		t.Error("non-json must be unchanged:", string(b))
+	}
+
+	h := r.headers(http.Header{"Token": {"secret"}, "Cookie": {"session=1"}, "Accept": {"*/*"}})
+	if h.Get("Token") != "***" || h.Get("Cookie") != "***" || h.Get("Accept") != "*/*" {
+		t.Error("invalid headers redaction:", h)
+	}
+}
@@ -0,0 +1,137 @@
+package tdclient
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RetryPolicy describes automatic retries of failed REST calls.
+//
+// Retried are network errors, timeouts, 429 and 5xx responses. GET, PUT and DELETE
+// requests are idempotent and always retried; POST is retried only when context
+// carries idempotency key, see WithIdempotencyKey.
+type RetryPolicy struct {
+	// MaxAttempts is total number of attempts, including first one
+	MaxAttempts int
+
+	// MinBackoff is delay after first failed attempt. Delay doubles with every attempt.
+	MinBackoff time.Duration
+
+	// MaxBackoff limits exponential delay. Retry-After header is respected even if greater.
+	MaxBackoff time.Duration
+
+	// Jitter is random part of delay, from 0 to 1
+	Jitter float64
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	MinBackoff:  250 * time.Millisecond,
+	MaxBackoff:  10 * time.Second,
+	Jitter:      0.2,
+}
+
+// WithRetryPolicy enables automatic retries. Retries are disabled by default.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(s *Session) error {
+		if p.MaxAttempts < 1 {
+			return errors.New("retry policy: max attempts must be positive")
+		}
+		if p.Jitter < 0 || p.Jitter > 1 {
+			return errors.New("retry policy: jitter must be between 0 and 1")
+		}
+		s.retryPolicy = &p
+		return nil
+	}
+}
+
+type idempotencyKeyCtxKey struct{}
+
+// WithIdempotencyKey marks POST calls made with ctx as safe to retry.
+// Key is unique id of created object, like tdapi.Message.MessageUid.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtxKey{}, key)
+}
+
+func idempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyCtxKey{}).(string)
+	return key
+}
+
+func (p *RetryPolicy) backoff(ctx context.Context, req *http.Request, attempt int, err error) (time.Duration, bool) {
+	if p == nil || req == nil || attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return 0, false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	case http.MethodPost:
+		if idempotencyKey(ctx) == "" {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if !retryableStatus(apiErr.StatusCode) {
+			return 0, false
+		}
+		if apiErr.RetryAfter > 0 {
+			return apiErr.RetryAfter, true
+		}
+	}
+
+	return p.delay(attempt), true
+}
+
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	d := p.MinBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(p.Jitter * rand.Float64() * float64(d))
+	}
+	return d
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || (code >= 500 && code != http.StatusNotImplemented)
+}
+
+// parseRetryAfter parses Retry-After header: delay in seconds or http date.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,81 @@
+package tdclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		switch {
+		case n == 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"ok": false, "error": "TooManyRequests"}`))
+		case n == 2:
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`<html>Bad Gateway</html>`))
+		default:
+			_, _ = w.Write([]byte(`{"ok": true, "result": "pong"}`))
+		}
+	}))
+	defer srv.Close()
+
+	s, err := NewSession(srv.URL, WithRetryPolicy(RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  time.Millisecond,
+	}))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	t.Run("get retried", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		if err := s.Ping(); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if n := atomic.LoadInt32(&calls); n != 3 {
+			t.Error("invalid attempts number:", n)
+		}
+	})
+
+	t.Run("post not retried", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		err := s.doPost(context.Background(), "/api/v4/something", map[string]string{}, nil)
+		if !IsRateLimited(err) {
+			t.Fatalf("want rate limit error, got: %v", err)
+		}
+		if n := atomic.LoadInt32(&calls); n != 1 {
+			t.Error("invalid attempts number:", n)
+		}
+	})
+
+	t.Run("post with idempotency key retried", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		ctx := WithIdempotencyKey(context.Background(), "uid")
+		if err := s.doPost(ctx, "/api/v4/something", map[string]string{}, nil); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if n := atomic.LoadInt32(&calls); n != 3 {
+			t.Error("invalid attempts number:", n)
+		}
+	})
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, MinBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	for attempt, want := range []time.Duration{0, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if attempt == 0 {
+			continue
+		}
+		if got := p.delay(attempt); got != want {
+			t.Errorf("attempt %d: want %s, got %s", attempt, want, got)
+		}
+	}
+}
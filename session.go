@@ -2,59 +2,68 @@ package tdclient
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"io"
-	"mime/multipart"
 	"net/http"
 	"net/url"
-	"os"
+	"sync"
 	"time"
 
 	"github.com/gorilla/schema"
-	"github.com/kpango/glg"
+	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
 	"github.com/tada-team/tdproto"
 )
 
-var httpClient = &http.Client{
-	Timeout: 10 * time.Second,
-	Transport: &http.Transport{
-		TLSClientConfig: &tls.Config{
-			// InsecureSkipVerify: true,
-			MinVersion: tls.VersionTLS12,
-		},
-		ForceAttemptHTTP2: true,
-	},
-}
-
 type Session struct {
 	server   url.URL
 	token    string
-	cookie   string
+	authMode AuthMode
+	jar      http.CookieJar
 	features *tdproto.Features
+	logger   Logger
+	redactor *redactor
+
+	httpClient *http.Client
+	transport  http.RoundTripper
+	wsDialer   *websocket.Dialer
+	tlsConfig  *tls.Config
+
+	proxy       func(*http.Request) (*url.URL, error)
+	rootCAs     *x509.CertPool
+	clientCerts []tls.Certificate
+	pins        map[string]bool
+
+	requestTimeout  time.Duration
+	uploadTimeout   time.Duration
+	downloadTimeout time.Duration
+	wsWriteTimeout  time.Duration
+	wsWaitTimeout   time.Duration
+
+	retryPolicy *RetryPolicy
+	middlewares []Middleware
+	cassette    *Cassette
+
+	tokenMu    sync.RWMutex
+	tokenStore TokenStore
+	reauth     ReauthFunc
+	reauthMu   sync.Mutex
 }
 
-var tdclientGlgLogger *glg.Glg = nil
-
-func createGlgLogger() {
-	tdclientGlgLogger = glg.New()
-
-	log_level_env, found := os.LookupEnv("TDCLIENT_LOG_LEVEL")
-	if found {
-		tdclientGlgLogger.SetLevel(glg.Atol(log_level_env))
-	} else {
-		tdclientGlgLogger.SetLevel(glg.WARN)
-	}
-}
-
-func NewSession(server string) (*Session, error) {
-	if tdclientGlgLogger == nil {
-		createGlgLogger()
+func NewSession(server string, opts ...Option) (*Session, error) {
+	s := &Session{
+		logger:          newGlgLogger(),
+		redactor:        newRedactor(),
+		requestTimeout:  defaultRequestTimeout,
+		uploadTimeout:   defaultUploadTimeout,
+		downloadTimeout: defaultDownloadTimeout,
+		wsWriteTimeout:  defaultWsWriteTimeout,
+		wsWaitTimeout:   defaultWsWaitTimeout,
 	}
 
-	s := &Session{}
-
 	u, err := url.Parse(server)
 	if err != nil {
 		return nil, err
@@ -65,43 +74,82 @@ func NewSession(server string) (*Session, error) {
 	}
 	s.server = *u
 
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+
+	s.setupClients()
+
+	if s.tokenStore != nil {
+		token, err := s.tokenStore.LoadToken()
+		if err != nil {
+			return nil, errors.Wrap(err, "load token fail")
+		}
+		s.token = token
+	}
+
 	return s, nil
 }
 
 func (s *Session) Features() (*tdproto.Features, error) {
+	return s.FeaturesContext(context.Background())
+}
+
+func (s *Session) FeaturesContext(ctx context.Context) (*tdproto.Features, error) {
 	if s.features == nil {
-		if err := s.doGet("/features.json", nil, &s.features); err != nil {
+		if err := s.doGet(ctx, "/features.json", nil, &s.features); err != nil {
 			return s.features, err
 		}
 	}
 	return s.features, nil
 }
 
+// SetToken sets session token and saves it to token store, if any. Empty token deletes saved one.
 func (s *Session) SetToken(v string) {
+	s.tokenMu.Lock()
 	s.token = v
+	s.tokenMu.Unlock()
+
+	if s.tokenStore == nil {
+		return
+	}
+
+	var err error
+	if v == "" {
+		err = s.tokenStore.DeleteToken()
+	} else {
+		err = s.tokenStore.SaveToken(v)
+	}
+	if err != nil {
+		s.logger.Warn("token store fail", "error", err)
+	}
 }
 
-func (s *Session) SetCookie(v string) {
-	s.cookie = v
+func (s *Session) getToken() string {
+	s.tokenMu.RLock()
+	defer s.tokenMu.RUnlock()
+	return s.token
 }
 
-func (s *Session) doGet(path string, params interface{}, resp interface{}) error {
-	return s.doRaw(http.MethodGet, path, params, nil, resp)
+func (s *Session) doGet(ctx context.Context, path string, params interface{}, resp interface{}) error {
+	return s.doRaw(ctx, http.MethodGet, path, params, nil, resp)
 }
 
-func (s *Session) doPost(path string, data, v interface{}) error {
-	return s.doRaw(http.MethodPost, path, nil, data, v)
+func (s *Session) doPost(ctx context.Context, path string, data, v interface{}) error {
+	return s.doRaw(ctx, http.MethodPost, path, nil, data, v)
 }
 
-func (s *Session) doDelete(path string, resp interface{}) error {
-	return s.doRaw(http.MethodDelete, path, nil, nil, resp)
+func (s *Session) doDelete(ctx context.Context, path string, resp interface{}) error {
+	return s.doRaw(ctx, http.MethodDelete, path, nil, nil, resp)
 }
 
-func (s *Session) doPut(path string, data, v interface{}) error {
-	return s.doRaw(http.MethodPut, path, nil, data, v)
+func (s *Session) doPut(ctx context.Context, path string, data, v interface{}) error {
+	return s.doRaw(ctx, http.MethodPut, path, nil, data, v)
 }
 
-func (s *Session) doRaw(method, path string, params, data, v interface{}) error {
+func (s *Session) doRaw(ctx context.Context, method, path string, params, data, v interface{}) error {
 	var u = s.server
 	u.Path = path
 	if params != nil {
@@ -119,97 +167,99 @@ func (s *Session) doRaw(method, path string, params, data, v interface{}) error
 	}
 	path = u.String()
 
-	var buf *bytes.Buffer
+	var b []byte
 	if data == nil {
-		tdclientGlgLogger.Debug(method, path)
-		buf = bytes.NewBuffer([]byte{})
+		s.logger.Debug("request", "method", method, "url", path)
 	} else {
-		tdclientGlgLogger.Debug(method, path, debugJSON(data))
-		b, err := json.Marshal(data)
+		var err error
+		b, err = json.Marshal(data)
 		if err != nil {
 			return errors.Wrap(err, "json marshal fail")
 		}
-		buf = bytes.NewBuffer(b)
+		s.logger.Debug("request", "method", method, "url", path, "body", s.redactor.request(u.Path, b))
 	}
 
-	req, err := http.NewRequest(method, path, buf)
+	_, respData, err := s.do(ctx, s.requestTimeout, func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, method, path, bytes.NewReader(b))
+	})
 	if err != nil {
-		return errors.Wrap(err, "new request fail")
-	}
-
-	if s.token != "" {
-		req.Header.Set("token", s.token)
-	}
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return errors.Wrap(err, "client do fail")
-	}
-	defer resp.Body.Close()
-
-	respData, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return errors.Wrap(err, "read body fail")
+		return err
 	}
 
 	if err := JSON.Unmarshal(respData, &v); err != nil {
 		return errors.Wrapf(err, "unmarshal fail on: %s", string(respData))
 	}
 
-	tdclientGlgLogger.Debug(debugJSON(v))
+	s.logger.Debug("response", "method", method, "url", path, "body", s.redactor.json(respData))
 
 	return nil
 }
 
-func (s *Session) uploadFile(path string, fname string, src io.ReadCloser, v interface{}) (http.Header, error) {
-	var u = s.server
-	u.Path = path
-
-	buf := &bytes.Buffer{}
+// do sends request made by newRequest and reads response body, retrying according to session retry policy.
+// Every attempt is limited by timeout. Unsuccessful responses are returned as *APIError.
+// Request rejected with 401 is repeated once after successful reauth, see WithReauth.
+func (s *Session) do(ctx context.Context, timeout time.Duration, newRequest func(ctx context.Context) (*http.Request, error)) (*http.Response, []byte, error) {
+	token := s.getToken()
 
-	writer := multipart.NewWriter(buf)
-	part, err := writer.CreateFormFile("file", fname)
-	if err != nil {
-		return nil, err
+	resp, respData, err := s.doRetry(ctx, timeout, newRequest)
+	if token == "" || s.reauth == nil || !IsUnauthorized(err) || ctx.Value(noReauthCtxKey{}) != nil {
+		return resp, respData, err
 	}
 
-	if _, err := io.Copy(part, src); err != nil {
-		return nil, err
+	if reauthErr := s.reauthorize(ctx, token); reauthErr != nil {
+		s.logger.Warn("reauth fail", "error", reauthErr)
+		return resp, respData, err
 	}
 
-	if err := src.Close(); err != nil {
-		return nil, err
-	}
+	return s.doRetry(ctx, timeout, newRequest)
+}
 
-	if err := writer.Close(); err != nil {
-		return nil, err
+func (s *Session) doRetry(ctx context.Context, timeout time.Duration, newRequest func(ctx context.Context) (*http.Request, error)) (*http.Response, []byte, error) {
+	for attempt := 1; ; attempt++ {
+		req, resp, respData, err := s.doOnce(ctx, timeout, newRequest)
+		if err == nil {
+			return resp, respData, nil
+		}
+
+		delay, ok := s.retryPolicy.backoff(ctx, req, attempt, err)
+		if !ok {
+			return resp, respData, err
+		}
+
+		s.logger.Warn("request failed, retry", "method", req.Method, "path", req.URL.Path, "attempt", attempt, "delay", delay, "error", err)
+		if err := sleepContext(ctx, delay); err != nil {
+			return resp, respData, err
+		}
 	}
+}
 
-	req, err := http.NewRequest("POST", u.String(), buf)
-	if err != nil {
-		return nil, err
+func (s *Session) doOnce(ctx context.Context, timeout time.Duration, newRequest func(ctx context.Context) (*http.Request, error)) (*http.Request, *http.Response, []byte, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	if s.token != "" {
-		req.Header.Set("token", s.token)
+	req, err := newRequest(ctx)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "new request fail")
 	}
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	s.authorize(req)
+	s.logger.Debug("http request", "method", req.Method, "url", req.URL.String(), "headers", s.redactor.headers(req.Header))
 
-	resp, err := httpClient.Do(req)
+	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return req, nil, nil, errors.Wrap(err, "client do fail")
 	}
 	defer resp.Body.Close()
 
+	s.storeCookies(req.URL, resp)
+
 	respData, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
-	}
-
-	if err := JSON.Unmarshal(respData, &v); err != nil {
-		return nil, errors.Wrapf(err, "unmarshal fail on: %s", string(respData))
+		return req, resp, nil, errors.Wrap(err, "read body fail")
 	}
 
-	return resp.Header, nil
+	return req, resp, respData, checkResponse(req, resp, respData)
 }
@@ -8,14 +8,27 @@ import (
 	"time"
 
 	"github.com/tada-team/kozma"
+	"github.com/tada-team/tdclient/tdclienttest"
 	"github.com/tada-team/tdproto"
 	"github.com/tada-team/tdproto/tdapi"
 )
 
 func TestSession(t *testing.T) {
-	testServer := mustEnv("TEST_SERVER")
-	testAccountPhone := mustEnv("TEST_ACCOUNT_PHONE")
-	testAccountCode := mustEnv("TEST_ACCOUNT_CODE")
+	testServer := os.Getenv("TEST_SERVER")
+	testAccountPhone := os.Getenv("TEST_ACCOUNT_PHONE")
+	testAccountCode := os.Getenv("TEST_ACCOUNT_CODE")
+
+	// run against fake server, unless real one is configured
+	if testServer == "" {
+		fake := tdclienttest.NewServer()
+		defer fake.Close()
+
+		testServer = fake.URL
+		testAccountPhone = fake.Account.Phone
+		testAccountCode = fake.Account.Code
+	} else if testAccountPhone == "" || testAccountCode == "" {
+		t.Fatal("TEST_ACCOUNT_PHONE and TEST_ACCOUNT_CODE variables must be set with TEST_SERVER")
+	}
 
 	s, err := NewSession(testServer)
 	if err != nil {
@@ -66,7 +79,7 @@ func TestSession(t *testing.T) {
 	})
 
 	if team.Uid == "" {
-		team, err = s.createTeam(tdapiTeam{Name: "tdclient test"})
+		team, err = s.CreateTeam(tdapi.Team{Name: "tdclient test"})
 		if err != nil {
 			t.Fatalf("%+v", err)
 		}
@@ -315,11 +328,3 @@ func TestSession(t *testing.T) {
 		})
 	})
 }
-
-func mustEnv(key string) string {
-	v := os.Getenv(key)
-	if v == "" {
-		panic(key + " variable not set")
-	}
-	return v
-}
@@ -0,0 +1,367 @@
+package tdclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tada-team/tdproto"
+	"github.com/tada-team/tdproto/tdapi"
+)
+
+// Builtin task statuses. Teams can have custom ones, see tdproto.TaskStatus.
+const (
+	TaskStatusNew  = "new"
+	TaskStatusDone = "done"
+)
+
+// TaskFilter selects tasks for GetTasks. Zero value returns first page of all tasks.
+type TaskFilter struct {
+	// Assignees, Statuses and Tags match any of listed values
+	Assignees []tdproto.JID
+	Statuses  []string
+	Tags      []string
+
+	// DeadlineFrom and DeadlineTo are inclusive bounds, zero means no bound
+	DeadlineFrom time.Time
+	DeadlineTo   time.Time
+
+	// Sort is "created", "last_message" or "deadline", with "-" prefix for descending order
+	Sort string
+
+	Offset int
+	Limit  int
+}
+
+func (f TaskFilter) query() (*tdapi.TaskFilter, error) {
+	q := new(tdapi.TaskFilter)
+
+	assignees := make([]string, 0, len(f.Assignees))
+	for _, jid := range f.Assignees {
+		if !validContactJid(jid) {
+			return nil, InvalidContactJid
+		}
+		assignees = append(assignees, jid.String())
+	}
+
+	q.Assignee = strings.Join(assignees, ",")
+	q.TaskStatus = strings.Join(f.Statuses, ",")
+	q.Tag = strings.Join(f.Tags, ",")
+	if !f.DeadlineFrom.IsZero() {
+		q.DeadlineGTE = tdproto.IsoDatetime(f.DeadlineFrom)
+	}
+	if !f.DeadlineTo.IsZero() {
+		q.DeadlineLTE = tdproto.IsoDatetime(f.DeadlineTo)
+	}
+	q.Sort = f.Sort
+	q.Offset = f.Offset
+	q.Limit = f.Limit
+
+	return q, nil
+}
+
+func validTaskJid(jid tdproto.JID) bool {
+	return jid.IsTask() && jid.Valid()
+}
+
+func (t *TeamClient) GetTasks(f TaskFilter) (tdproto.PaginatedChats, error) {
+	return t.GetTasksContext(context.Background(), f)
+}
+
+// GetTasksContext returns one page of tasks. Next page starts at Offset + len(Objects), Count is total number of tasks.
+func (t *TeamClient) GetTasksContext(ctx context.Context, f TaskFilter) (tdproto.PaginatedChats, error) {
+	q, err := f.query()
+	if err != nil {
+		return tdproto.PaginatedChats{}, err
+	}
+
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.PaginatedChats `json:"result"`
+	})
+
+	if err := t.session.doGet(ctx, fmt.Sprintf("/api/v4/teams/%s/tasks", t.uid), q, resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+func (t *TeamClient) GetTask(jid tdproto.JID) (tdproto.Chat, error) {
+	return t.GetTaskContext(context.Background(), jid)
+}
+
+func (t *TeamClient) GetTaskContext(ctx context.Context, jid tdproto.JID) (tdproto.Chat, error) {
+	if !validTaskJid(jid) {
+		return tdproto.Chat{}, InvalidTaskJid
+	}
+
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.Chat `json:"result"`
+	})
+
+	if err := t.session.doGet(ctx, t.taskPath(jid), nil, resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+func (t *TeamClient) UpdateTask(jid tdproto.JID, req tdapi.Task) (tdproto.Chat, error) {
+	return t.UpdateTaskContext(context.Background(), jid, req)
+}
+
+// UpdateTaskContext changes non-empty fields of req. Use Set... methods to clear deadline, tags or observers.
+func (t *TeamClient) UpdateTaskContext(ctx context.Context, jid tdproto.JID, req tdapi.Task) (tdproto.Chat, error) {
+	if !validTaskJid(jid) {
+		return tdproto.Chat{}, InvalidTaskJid
+	}
+	if req.Assignee != "" && !validContactJid(req.Assignee) {
+		return tdproto.Chat{}, InvalidContactJid
+	}
+	for _, observer := range req.Observers {
+		if !validContactJid(observer) {
+			return tdproto.Chat{}, InvalidContactJid
+		}
+	}
+	return t.updateTask(ctx, jid, req)
+}
+
+func (t *TeamClient) SetTaskStatus(jid tdproto.JID, status string) (tdproto.Chat, error) {
+	return t.SetTaskStatusContext(context.Background(), jid, status)
+}
+
+// SetTaskStatusContext moves task to given status: TaskStatusNew, TaskStatusDone or custom team status.
+func (t *TeamClient) SetTaskStatusContext(ctx context.Context, jid tdproto.JID, status string) (tdproto.Chat, error) {
+	if status == "" {
+		return tdproto.Chat{}, InvalidTaskStatus
+	}
+	return t.UpdateTaskContext(ctx, jid, tdapi.Task{TaskStatus: status})
+}
+
+func (t *TeamClient) ReopenTask(jid tdproto.JID) (tdproto.Chat, error) {
+	return t.ReopenTaskContext(context.Background(), jid)
+}
+
+func (t *TeamClient) ReopenTaskContext(ctx context.Context, jid tdproto.JID) (tdproto.Chat, error) {
+	return t.SetTaskStatusContext(ctx, jid, TaskStatusNew)
+}
+
+func (t *TeamClient) SetTaskAssignee(jid, assignee tdproto.JID) (tdproto.Chat, error) {
+	return t.SetTaskAssigneeContext(context.Background(), jid, assignee)
+}
+
+// SetTaskAssigneeContext changes task assignee. Empty assignee unassigns task.
+func (t *TeamClient) SetTaskAssigneeContext(ctx context.Context, jid, assignee tdproto.JID) (tdproto.Chat, error) {
+	if !validTaskJid(jid) {
+		return tdproto.Chat{}, InvalidTaskJid
+	}
+	if assignee != "" && !validContactJid(assignee) {
+		return tdproto.Chat{}, InvalidContactJid
+	}
+	return t.updateTask(ctx, jid, map[string]interface{}{"assignee": assignee})
+}
+
+func (t *TeamClient) SetTaskDeadline(jid tdproto.JID, deadline time.Time) (tdproto.Chat, error) {
+	return t.SetTaskDeadlineContext(context.Background(), jid, deadline)
+}
+
+// SetTaskDeadlineContext changes task deadline. Zero deadline removes it.
+func (t *TeamClient) SetTaskDeadlineContext(ctx context.Context, jid tdproto.JID, deadline time.Time) (tdproto.Chat, error) {
+	if !validTaskJid(jid) {
+		return tdproto.Chat{}, InvalidTaskJid
+	}
+	var v string
+	if !deadline.IsZero() {
+		v = tdproto.IsoDatetime(deadline)
+	}
+	return t.updateTask(ctx, jid, map[string]interface{}{"deadline": v})
+}
+
+func (t *TeamClient) SetTaskDescription(jid tdproto.JID, description string) (tdproto.Chat, error) {
+	return t.SetTaskDescriptionContext(context.Background(), jid, description)
+}
+
+func (t *TeamClient) SetTaskDescriptionContext(ctx context.Context, jid tdproto.JID, description string) (tdproto.Chat, error) {
+	if !validTaskJid(jid) {
+		return tdproto.Chat{}, InvalidTaskJid
+	}
+	if strings.TrimSpace(description) == "" {
+		return tdproto.Chat{}, InvalidTaskDescription
+	}
+	return t.updateTask(ctx, jid, map[string]interface{}{"description": description})
+}
+
+func (t *TeamClient) SetTaskObservers(jid tdproto.JID, observers []tdproto.JID) (tdproto.Chat, error) {
+	return t.SetTaskObserversContext(context.Background(), jid, observers)
+}
+
+// SetTaskObserversContext replaces task observers. Empty list removes all of them.
+func (t *TeamClient) SetTaskObserversContext(ctx context.Context, jid tdproto.JID, observers []tdproto.JID) (tdproto.Chat, error) {
+	if !validTaskJid(jid) {
+		return tdproto.Chat{}, InvalidTaskJid
+	}
+	for _, observer := range observers {
+		if !validContactJid(observer) {
+			return tdproto.Chat{}, InvalidContactJid
+		}
+	}
+	if observers == nil {
+		observers = []tdproto.JID{}
+	}
+	return t.updateTask(ctx, jid, map[string]interface{}{"observers": observers})
+}
+
+func (t *TeamClient) SetTaskTags(jid tdproto.JID, tags []string) (tdproto.Chat, error) {
+	return t.SetTaskTagsContext(context.Background(), jid, tags)
+}
+
+// SetTaskTagsContext replaces task tags. Empty list removes all of them.
+func (t *TeamClient) SetTaskTagsContext(ctx context.Context, jid tdproto.JID, tags []string) (tdproto.Chat, error) {
+	if !validTaskJid(jid) {
+		return tdproto.Chat{}, InvalidTaskJid
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+	return t.updateTask(ctx, jid, map[string]interface{}{"tags": tags})
+}
+
+func (t *TeamClient) updateTask(ctx context.Context, jid tdproto.JID, req interface{}) (tdproto.Chat, error) {
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.Chat `json:"result"`
+	})
+
+	if err := t.session.doPost(ctx, t.taskPath(jid), req, resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+func (t *TeamClient) taskPath(jid tdproto.JID) string {
+	return fmt.Sprintf("/api/v4/teams/%s/tasks/%s", t.uid, jid)
+}
+
+func (s *Session) GetTasks(teamUid string, f TaskFilter) (tdproto.PaginatedChats, error) {
+	return s.GetTasksContext(context.Background(), teamUid, f)
+}
+
+func (s *Session) GetTasksContext(ctx context.Context, teamUid string, f TaskFilter) (tdproto.PaginatedChats, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.PaginatedChats{}, err
+	}
+	return team.GetTasksContext(ctx, f)
+}
+
+func (s *Session) GetTask(teamUid string, jid tdproto.JID) (tdproto.Chat, error) {
+	return s.GetTaskContext(context.Background(), teamUid, jid)
+}
+
+func (s *Session) GetTaskContext(ctx context.Context, teamUid string, jid tdproto.JID) (tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Chat{}, err
+	}
+	return team.GetTaskContext(ctx, jid)
+}
+
+func (s *Session) UpdateTask(teamUid string, jid tdproto.JID, req tdapi.Task) (tdproto.Chat, error) {
+	return s.UpdateTaskContext(context.Background(), teamUid, jid, req)
+}
+
+func (s *Session) UpdateTaskContext(ctx context.Context, teamUid string, jid tdproto.JID, req tdapi.Task) (tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Chat{}, err
+	}
+	return team.UpdateTaskContext(ctx, jid, req)
+}
+
+func (s *Session) SetTaskStatus(teamUid string, jid tdproto.JID, status string) (tdproto.Chat, error) {
+	return s.SetTaskStatusContext(context.Background(), teamUid, jid, status)
+}
+
+func (s *Session) SetTaskStatusContext(ctx context.Context, teamUid string, jid tdproto.JID, status string) (tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Chat{}, err
+	}
+	return team.SetTaskStatusContext(ctx, jid, status)
+}
+
+func (s *Session) ReopenTask(teamUid string, jid tdproto.JID) (tdproto.Chat, error) {
+	return s.ReopenTaskContext(context.Background(), teamUid, jid)
+}
+
+func (s *Session) ReopenTaskContext(ctx context.Context, teamUid string, jid tdproto.JID) (tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Chat{}, err
+	}
+	return team.ReopenTaskContext(ctx, jid)
+}
+
+func (s *Session) SetTaskAssignee(teamUid string, jid, assignee tdproto.JID) (tdproto.Chat, error) {
+	return s.SetTaskAssigneeContext(context.Background(), teamUid, jid, assignee)
+}
+
+func (s *Session) SetTaskAssigneeContext(ctx context.Context, teamUid string, jid, assignee tdproto.JID) (tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Chat{}, err
+	}
+	return team.SetTaskAssigneeContext(ctx, jid, assignee)
+}
+
+func (s *Session) SetTaskDeadline(teamUid string, jid tdproto.JID, deadline time.Time) (tdproto.Chat, error) {
+	return s.SetTaskDeadlineContext(context.Background(), teamUid, jid, deadline)
+}
+
+func (s *Session) SetTaskDeadlineContext(ctx context.Context, teamUid string, jid tdproto.JID, deadline time.Time) (tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Chat{}, err
+	}
+	return team.SetTaskDeadlineContext(ctx, jid, deadline)
+}
+
+func (s *Session) SetTaskDescription(teamUid string, jid tdproto.JID, description string) (tdproto.Chat, error) {
+	return s.SetTaskDescriptionContext(context.Background(), teamUid, jid, description)
+}
+
+func (s *Session) SetTaskDescriptionContext(ctx context.Context, teamUid string, jid tdproto.JID, description string) (tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Chat{}, err
+	}
+	return team.SetTaskDescriptionContext(ctx, jid, description)
+}
+
+func (s *Session) SetTaskObservers(teamUid string, jid tdproto.JID, observers []tdproto.JID) (tdproto.Chat, error) {
+	return s.SetTaskObserversContext(context.Background(), teamUid, jid, observers)
+}
+
+func (s *Session) SetTaskObserversContext(ctx context.Context, teamUid string, jid tdproto.JID, observers []tdproto.JID) (tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Chat{}, err
+	}
+	return team.SetTaskObserversContext(ctx, jid, observers)
+}
+
+func (s *Session) SetTaskTags(teamUid string, jid tdproto.JID, tags []string) (tdproto.Chat, error) {
+	return s.SetTaskTagsContext(context.Background(), teamUid, jid, tags)
+}
+
+func (s *Session) SetTaskTagsContext(ctx context.Context, teamUid string, jid tdproto.JID, tags []string) (tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Chat{}, err
+	}
+	return team.SetTaskTagsContext(ctx, jid, tags)
+}
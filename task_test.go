@@ -0,0 +1,279 @@
+package tdclient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/tada-team/tdclient/tdclienttest"
+	"github.com/tada-team/tdproto"
+	"github.com/tada-team/tdproto/tdapi"
+)
+
+func TestTasks(t *testing.T) {
+	srv, s, teamUid := newTestSession(t)
+
+	team, err := s.Team(teamUid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contacts, err := team.Contacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, bob := contacts[1].Jid, contacts[2].Jid
+
+	deadline := time.Date(2030, 1, 15, 12, 0, 0, 0, time.UTC)
+	task, err := team.CreateTask(tdapi.Task{Description: "first", Assignee: alice, Tags: []string{"backend"}})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if _, err := team.CreateTask(tdapi.Task{Description: "second", Assignee: bob, Deadline: tdproto.IsoDatetime(deadline)}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if _, err := team.CreateTask(tdapi.Task{Description: "third", Assignee: bob}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	t.Run("validation", func(t *testing.T) {
+		if _, err := team.GetTask(alice); err != InvalidTaskJid {
+			t.Error("GetTask: want InvalidTaskJid, got:", err)
+		}
+		if _, err := team.SetTaskAssignee(task.Jid, "nobody"); err != InvalidContactJid {
+			t.Error("SetTaskAssignee: want InvalidContactJid, got:", err)
+		}
+		if _, err := team.GetTasks(TaskFilter{Assignees: []tdproto.JID{task.Jid}}); err != InvalidContactJid {
+			t.Error("GetTasks: want InvalidContactJid, got:", err)
+		}
+		if _, err := team.SetTaskStatus(task.Jid, ""); err != InvalidTaskStatus {
+			t.Error("SetTaskStatus: want InvalidTaskStatus, got:", err)
+		}
+		if _, err := team.SetTaskDescription(task.Jid, " "); err != InvalidTaskDescription {
+			t.Error("SetTaskDescription: want InvalidTaskDescription, got:", err)
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			filter TaskFilter
+			want   int
+		}{
+			"all":      {TaskFilter{}, 3},
+			"assignee": {TaskFilter{Assignees: []tdproto.JID{bob}}, 2},
+			"tag":      {TaskFilter{Tags: []string{"backend", "frontend"}}, 1},
+			"deadline": {TaskFilter{DeadlineFrom: deadline.AddDate(0, 0, -1), DeadlineTo: deadline}, 1},
+			"page":     {TaskFilter{Offset: 1, Limit: 1}, 1},
+		} {
+			page, err := team.GetTasks(tc.filter)
+			if err != nil {
+				t.Fatalf("%s: %+v", name, err)
+			}
+			if len(page.Objects) != tc.want {
+				t.Errorf("%s: want %d tasks, got: %d", name, tc.want, len(page.Objects))
+			}
+		}
+	})
+
+	t.Run("status", func(t *testing.T) {
+		closed, err := s.CloseTask(team.Uid(), task.Jid.String())
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if closed.TaskStatus != TaskStatusDone {
+			t.Error("invalid status:", closed.TaskStatus)
+		}
+
+		page, err := s.GetTasks(team.Uid(), TaskFilter{Statuses: []string{TaskStatusDone}})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if len(page.Objects) != 1 || page.Objects[0].Jid != task.Jid {
+			t.Error("invalid done tasks:", page.Objects)
+		}
+
+		reopened, err := s.ReopenTask(team.Uid(), task.Jid)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if reopened.TaskStatus != TaskStatusNew {
+			t.Error("invalid status:", reopened.TaskStatus)
+		}
+	})
+
+	t.Run("edit", func(t *testing.T) {
+		if _, err := s.SetTaskAssignee(team.Uid(), task.Jid, bob); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if _, err := s.SetTaskDeadline(team.Uid(), task.Jid, deadline); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if _, err := s.SetTaskDescription(team.Uid(), task.Jid, "first, updated"); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if _, err := s.SetTaskObservers(team.Uid(), task.Jid, []tdproto.JID{alice}); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if _, err := s.SetTaskTags(team.Uid(), task.Jid, nil); err != nil {
+			t.Fatalf("%+v", err)
+		}
+
+		got, err := s.GetTask(team.Uid(), task.Jid)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if got.Assignee != bob || got.Deadline == "" || got.Description != "first, updated" {
+			t.Error("invalid task:", got)
+		}
+		if len(got.Observers) != 1 || got.Observers[0] != alice || len(got.Tags) != 0 {
+			t.Error("invalid observers or tags:", got.Observers, got.Tags)
+		}
+
+		if got, err = s.SetTaskDeadline(team.Uid(), task.Jid, time.Time{}); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if got.Deadline != "" {
+			t.Error("deadline not removed:", got.Deadline)
+		}
+
+		if got, err = s.UpdateTask(team.Uid(), task.Jid, tdapi.Task{Tags: []string{"urgent"}, Public: true}); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if len(got.Tags) != 1 || !got.Public || got.Assignee != bob {
+			t.Error("invalid task:", got)
+		}
+
+		// task update is POST, as documented in tdproto api paths
+		srv.Fail(tdclienttest.Failure{Method: http.MethodPost, Path: "/api/v4/teams/" + team.Uid() + "/tasks/" + task.Jid.String(), Status: http.StatusServiceUnavailable})
+		if _, err := s.SetTaskTags(team.Uid(), task.Jid, nil); !IsServerError(err) {
+			t.Error("update must be sent with POST, got:", err)
+		}
+	})
+}
+
+func TestTaskChecklist(t *testing.T) {
+	_, s, teamUid := newTestSession(t)
+	task, err := s.CreateTask(teamUid, tdapi.Task{Description: "release", Items: []string{"build"}})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(task.Items) != 1 {
+		t.Fatal("invalid items:", task.Items)
+	}
+
+	t.Run("items", func(t *testing.T) {
+		if _, err := s.AddTaskItem(teamUid, task.Jid, ""); err != InvalidTaskItem {
+			t.Error("AddTaskItem: want InvalidTaskItem, got:", err)
+		}
+
+		test, err := s.AddTaskItem(teamUid, task.Jid, "test")
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		deploy, err := s.AddTaskItem(teamUid, task.Jid, "deploy")
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+
+		item, err := s.CheckTaskItem(teamUid, task.Jid, test.Uid)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if !item.Checked {
+			t.Error("not checked")
+		}
+		if item, err = s.UncheckTaskItem(teamUid, task.Jid, test.Uid); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if item.Checked {
+			t.Error("still checked")
+		}
+
+		items, err := s.ReorderTaskItems(teamUid, task.Jid, []string{deploy.Uid, test.Uid})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		var texts []string
+		for _, item := range items {
+			texts = append(texts, item.Text)
+		}
+		if len(texts) != 3 || texts[0] != "deploy" || texts[1] != "test" || texts[2] != "build" {
+			t.Error("invalid order:", texts)
+		}
+
+		// unlisted items keep their relative order after listed ones
+		if items, err = s.ReorderTaskItems(teamUid, task.Jid, []string{task.Items[0].Uid}); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		texts = texts[:0]
+		for i, item := range items {
+			texts = append(texts, item.Text)
+			if item.SortOrdering != uint(i+1) {
+				t.Error("invalid sort ordering:", item.Text, item.SortOrdering)
+			}
+		}
+		if len(texts) != 3 || texts[0] != "build" || texts[1] != "deploy" || texts[2] != "test" {
+			t.Error("invalid order:", texts)
+		}
+
+		// server shifts following items on every update, result must not depend on it
+		if items, err = s.ReorderTaskItems(teamUid, task.Jid, []string{test.Uid, deploy.Uid, task.Items[0].Uid}); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		texts = texts[:0]
+		for _, item := range items {
+			texts = append(texts, item.Text)
+		}
+		if len(texts) != 3 || texts[0] != "test" || texts[1] != "deploy" || texts[2] != "build" {
+			t.Error("invalid order:", texts)
+		}
+
+		if _, err := s.ReorderTaskItems(teamUid, task.Jid, []string{deploy.Uid, "unknown"}); err != InvalidTaskItem {
+			t.Error("ReorderTaskItems: want InvalidTaskItem, got:", err)
+		}
+	})
+
+	t.Run("subtasks", func(t *testing.T) {
+		sub, err := s.CreateSubtask(teamUid, task.Jid, tdapi.Task{Description: "write changelog"})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		other, err := s.CreateTask(teamUid, tdapi.Task{Description: "update docs"})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if _, err := s.LinkSubtask(teamUid, task.Jid, other.Jid); err != nil {
+			t.Fatalf("%+v", err)
+		}
+
+		subtasks, err := s.Subtasks(teamUid, task.Jid)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if len(subtasks) != 2 || subtasks[0].Jid != sub.Jid || subtasks[1].Jid != other.Jid {
+			t.Error("invalid subtasks:", subtasks)
+		}
+
+		child, err := s.GetTask(teamUid, sub.Jid)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if len(child.Parents) != 1 || child.Parents[0].Jid != task.Jid {
+			t.Error("invalid parents:", child.Parents)
+		}
+	})
+
+	t.Run("comments", func(t *testing.T) {
+		if _, err := s.SendTaskComment(teamUid, "g-"+tdproto.JID(task.Jid.Uid()), "hi"); err != InvalidTaskJid {
+			t.Error("SendTaskComment: want InvalidTaskJid, got:", err)
+		}
+
+		msg, err := s.SendTaskComment(teamUid, task.Jid, "50% done")
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if msg.Chat != task.Jid || msg.PushText != "50% done" {
+			t.Error("invalid message:", msg)
+		}
+	})
+}
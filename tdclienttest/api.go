@@ -0,0 +1,898 @@
+package tdclienttest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (s *Server) serveAuth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", nil)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/api/v4/auth/sms/send-code":
+		req := new(struct {
+			Phone string `json:"phone"`
+		})
+		if !readJSON(w, r, req) {
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if req.Phone != s.Account.Phone {
+			writeError(w, http.StatusBadRequest, "InvalidData", map[string]string{"phone": "unknown phone"})
+			return
+		}
+		writeResult(w, map[string]interface{}{
+			"code_length": len(s.Account.Code),
+		})
+
+	case "/api/v4/auth/sms/get-token":
+		req := new(struct {
+			Phone string `json:"phone"`
+			Code  string `json:"code"`
+		})
+		if !readJSON(w, r, req) {
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if req.Phone != s.Account.Phone || req.Code != s.Account.Code {
+			writeError(w, http.StatusBadRequest, "InvalidData", map[string]string{"code": "invalid code"})
+			return
+		}
+		s.writeAuth(w)
+
+	case "/api/v4/auth/password/get-token":
+		req := new(struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		})
+		if !readJSON(w, r, req) {
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if req.Username != s.Account.Username || req.Password != s.Account.Password {
+			writeError(w, http.StatusBadRequest, "InvalidData", map[string]string{"password": "invalid login or password"})
+			return
+		}
+		s.writeAuth(w)
+
+	case "/api/v4/auth/logout":
+		if !s.authorized(r) {
+			writeError(w, http.StatusUnauthorized, "InvalidToken", nil)
+			return
+		}
+		s.RevokeToken()
+		writeResult(w, nil)
+
+	default:
+		writeError(w, http.StatusNotFound, "NotFound", nil)
+	}
+}
+
+// writeAuth issues new token if previous one was revoked. Called under lock.
+func (s *Server) writeAuth(w http.ResponseWriter) {
+	if s.Account.Token == "" {
+		s.Account.Token = newToken()
+	}
+	writeResult(w, map[string]interface{}{
+		"token": s.Account.Token,
+		"me":    s.renderMe(),
+	})
+}
+
+// serveTeams routes /api/v4/teams/...
+func (s *Server) serveTeams(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v4/teams"), "/"), "/")
+	if parts[0] == "" {
+		parts = nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(parts) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			teams := make([]interface{}, 0, len(s.order))
+			for _, uid := range s.order {
+				teams = append(teams, s.renderTeam(s.teams[uid]))
+			}
+			writeResult(w, teams)
+		case http.MethodPost:
+			req := new(struct {
+				Name string `json:"name"`
+			})
+			if !readJSON(w, r, req) {
+				return
+			}
+			if req.Name == "" {
+				writeError(w, http.StatusBadRequest, "InvalidData", map[string]string{"name": "required"})
+				return
+			}
+			writeResult(w, s.renderTeam(s.addTeam(req.Name)))
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", nil)
+		}
+		return
+	}
+
+	t := s.teams[parts[0]]
+	if t == nil {
+		writeError(w, http.StatusNotFound, "NotFound", map[string]string{"team": parts[0]})
+		return
+	}
+
+	route := r.Method + " " + strings.Join(append([]string{""}, parts[1:]...), "/")
+	switch {
+	case route == "GET ":
+		writeResult(w, s.renderTeam(t))
+	case route == "PUT ":
+		s.updateTeam(w, r, t)
+	case route == "DELETE ":
+		s.dropTeam(t.uid)
+		v := s.renderTeam(t)
+		v["is_archive"] = true
+		writeResult(w, v)
+	case route == "GET /contacts":
+		writeResult(w, renderContacts(t.contacts))
+	case route == "POST /contacts":
+		s.addContact(w, r, t)
+	case matchRoute(parts, "", "contacts", "*") && r.Method == http.MethodGet:
+		s.withContact(w, t, parts[2], func(c *contact) { writeResult(w, renderContact(c)) })
+	case matchRoute(parts, "", "contacts", "*") && r.Method == http.MethodPost:
+		s.withContact(w, t, parts[2], func(c *contact) { s.updateContact(w, r, c) })
+	case matchRoute(parts, "", "contacts", "*") && r.Method == http.MethodDelete:
+		s.withContact(w, t, parts[2], func(c *contact) {
+			if c.status == "owner" {
+				writeError(w, http.StatusForbidden, "AccessDenied", map[string]string{"status": "owner"})
+				return
+			}
+			// removal of other contacts needs admin rights, anyone but owner can remove himself
+			if me := t.contact(t.me); c.jid != t.me && me.status != "owner" && me.status != "admin" {
+				writeError(w, http.StatusForbidden, "AccessDenied", map[string]string{"status": me.status})
+				return
+			}
+			t.dropContact(c.jid)
+			if c.jid == t.me {
+				// account left team
+				s.dropTeam(t.uid)
+			}
+			writeResult(w, renderContact(c))
+		})
+	case route == "GET /chats":
+		s.getChats(w, r, t)
+	case matchRoute(parts, "", "chats", "*", "messages") && r.Method == http.MethodPost:
+		s.sendMessage(w, r, t, parts[2])
+	case matchRoute(parts, "", "chats", "*", "messages", "*") && r.Method == http.MethodPost:
+		s.editMessage(w, r, t, parts[2], parts[4])
+	case matchRoute(parts, "", "chats", "*", "messages", "*") && r.Method == http.MethodDelete:
+		s.deleteMessage(w, t, parts[2], parts[4])
+	case matchRoute(parts, "", "messages", "*") && r.Method == http.MethodGet:
+		s.getMessages(w, r, t, parts[2])
+	case route == "GET /tasks":
+		s.getTasks(w, r, t)
+	case route == "POST /tasks":
+		s.createTask(w, r, t)
+	case matchRoute(parts, "", "tasks", "*") && r.Method == http.MethodGet:
+		s.withChat(w, t, parts[2], taskChatType, func(c *chat) { writeResult(w, renderChat(c)) })
+	case matchRoute(parts, "", "tasks", "*") && r.Method == http.MethodPost:
+		s.withChat(w, t, parts[2], taskChatType, func(c *chat) { s.updateTask(w, r, c) })
+	case matchRoute(parts, "", "tasks", "*", "items") && r.Method == http.MethodPost:
+		s.withChat(w, t, parts[2], taskChatType, func(c *chat) { s.addTaskItem(w, r, t, c) })
+	case matchRoute(parts, "", "tasks", "*", "items", "*") && r.Method == http.MethodPost:
+		s.withChat(w, t, parts[2], taskChatType, func(c *chat) { s.updateTaskItem(w, r, c, parts[4]) })
+	case route == "GET /groups":
+		writeResult(w, renderChats(t.chatsByType(groupChatType)))
+	case route == "POST /groups":
+		s.createGroup(w, r, t)
+	case matchRoute(parts, "", "groups", "*") && r.Method == http.MethodGet:
+		s.withChat(w, t, parts[2], groupChatType, func(c *chat) { writeResult(w, renderChat(c)) })
+	case matchRoute(parts, "", "groups", "*") && r.Method == http.MethodPut:
+		s.withChat(w, t, parts[2], groupChatType, func(c *chat) { s.updateGroup(w, r, c) })
+	case matchRoute(parts, "", "groups", "*") && r.Method == http.MethodDelete:
+		s.withChat(w, t, parts[2], groupChatType, func(c *chat) {
+			t.dropChat(c.jid)
+			writeResult(w, nil)
+		})
+	case matchRoute(parts, "", "groups", "*", "members") && r.Method == http.MethodGet:
+		s.withChat(w, t, parts[2], groupChatType, func(c *chat) {
+			members := make([]interface{}, 0, len(c.members))
+			for _, m := range c.members {
+				members = append(members, renderMember(m))
+			}
+			writeResult(w, map[string]interface{}{"members": members})
+		})
+	case matchRoute(parts, "", "groups", "*", "members") && r.Method == http.MethodPost:
+		s.withChat(w, t, parts[2], groupChatType, func(c *chat) { s.addGroupMember(w, r, t, c) })
+	case matchRoute(parts, "", "groups", "*", "members", "*") && r.Method == http.MethodPut:
+		s.withChat(w, t, parts[2], groupChatType, func(c *chat) { s.updateGroupMember(w, r, c, parts[4]) })
+	case matchRoute(parts, "", "groups", "*", "members", "*") && r.Method == http.MethodDelete:
+		s.withChat(w, t, parts[2], groupChatType, func(c *chat) {
+			for i, m := range c.members {
+				if m.jid == parts[4] {
+					c.members = append(c.members[:i], c.members[i+1:]...)
+					writeResult(w, nil)
+					return
+				}
+			}
+			writeError(w, http.StatusNotFound, "NotFound", map[string]string{"member": parts[4]})
+		})
+	default:
+		writeError(w, http.StatusNotFound, "NotFound", nil)
+	}
+}
+
+// matchRoute matches path parts after team uid. Pattern "*" matches any part.
+func matchRoute(parts []string, pattern ...string) bool {
+	// pattern[0] stands for team uid
+	if len(parts) != len(pattern) {
+		return false
+	}
+	for i := 1; i < len(parts); i++ {
+		if pattern[i] != "*" && pattern[i] != parts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) withChat(w http.ResponseWriter, t *team, jid, chatType string, fn func(c *chat)) {
+	c := t.chat(jid)
+	if c == nil || c.chatType != chatType {
+		writeError(w, http.StatusNotFound, "NotFound", map[string]string{"jid": jid})
+		return
+	}
+	fn(c)
+}
+
+func (s *Server) addContact(w http.ResponseWriter, r *http.Request, t *team) {
+	req := new(struct {
+		Phone string `json:"phone"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	if req.Phone == "" {
+		writeError(w, http.StatusBadRequest, "InvalidData", map[string]string{"phone": "required"})
+		return
+	}
+	for _, c := range t.contacts {
+		if c.phone == req.Phone {
+			writeResult(w, renderContact(c))
+			return
+		}
+	}
+	writeResult(w, renderContact(t.addContact(req.Phone, req.Phone)))
+}
+
+func (s *Server) updateTeam(w http.ResponseWriter, r *http.Request, t *team) {
+	req := make(map[string]interface{})
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if v, ok := req["name"]; ok {
+		name, _ := v.(string)
+		if strings.TrimSpace(name) == "" {
+			writeError(w, http.StatusBadRequest, "InvalidData", map[string]string{"name": "required"})
+			return
+		}
+		t.name = name
+		delete(req, "name")
+	}
+	for k, v := range req {
+		switch k {
+		case "uid", "me", "gentime", "is_archive":
+		default:
+			t.settings[k] = v
+		}
+	}
+	writeResult(w, s.renderTeam(t))
+}
+
+func (s *Server) withContact(w http.ResponseWriter, t *team, jid string, fn func(c *contact)) {
+	c := t.contact(jid)
+	if c == nil {
+		writeError(w, http.StatusNotFound, "NotFound", map[string]string{"jid": jid})
+		return
+	}
+	fn(c)
+}
+
+func (s *Server) updateContact(w http.ResponseWriter, r *http.Request, c *contact) {
+	req := new(struct {
+		DisplayName  *string                `json:"display_name"`
+		Role         *string                `json:"role"`
+		ContactPhone *string                `json:"contact_phone"`
+		ContactEmail *string                `json:"contact_email"`
+		Status       *string                `json:"status"`
+		IsArchive    *bool                  `json:"is_archive"`
+		CustomFields map[string]interface{} `json:"custom_fields"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	if req.Status != nil {
+		switch *req.Status {
+		case "admin", "member", "guest":
+		default:
+			writeError(w, http.StatusBadRequest, "InvalidData", map[string]string{"status": "invalid"})
+			return
+		}
+		if c.status == "owner" {
+			writeError(w, http.StatusForbidden, "AccessDenied", map[string]string{"status": "owner"})
+			return
+		}
+		c.status = *req.Status
+	}
+	if req.DisplayName != nil {
+		c.displayName = *req.DisplayName
+	}
+	if req.Role != nil {
+		c.role = *req.Role
+	}
+	if req.ContactPhone != nil {
+		c.phone = *req.ContactPhone
+	}
+	if req.ContactEmail != nil {
+		c.email = *req.ContactEmail
+	}
+	if req.IsArchive != nil {
+		c.isArchive = *req.IsArchive
+	}
+	if req.CustomFields != nil {
+		c.customFields = req.CustomFields
+	}
+	writeResult(w, renderContact(c))
+}
+
+func (s *Server) getChats(w http.ResponseWriter, r *http.Request, t *team) {
+	q := r.URL.Query()
+	writeChatsPage(w, q, t.chatsByType(q.Get("chat_type")))
+}
+
+func (s *Server) getTasks(w http.ResponseWriter, r *http.Request, t *team) {
+	q := r.URL.Query()
+	deadlineFrom := parseDate(q.Get("deadline_gte"))
+	deadlineTo := parseDate(q.Get("deadline_lte"))
+
+	var tasks []*chat
+	for _, c := range t.chatsByType(taskChatType) {
+		if !matchList(q.Get("assignee"), c.assignee) || !matchList(q.Get("task_status"), c.taskStatus) {
+			continue
+		}
+		if tags := q.Get("tag"); tags != "" && !matchAny(tags, c.tags) {
+			continue
+		}
+		if !deadlineFrom.IsZero() || !deadlineTo.IsZero() {
+			deadline := parseDate(c.deadline)
+			if deadline.IsZero() || (!deadlineFrom.IsZero() && deadline.Before(deadlineFrom)) || (!deadlineTo.IsZero() && deadline.After(deadlineTo)) {
+				continue
+			}
+		}
+		tasks = append(tasks, c)
+	}
+
+	writeChatsPage(w, q, tasks)
+}
+
+// matchList reports whether v is in comma separated list. Empty list matches anything.
+func matchList(list, v string) bool {
+	return list == "" || matchAny(list, []string{v})
+}
+
+func matchAny(list string, values []string) bool {
+	for _, item := range strings.Split(list, ",") {
+		for _, v := range values {
+			if item == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func writeChatsPage(w http.ResponseWriter, q url.Values, chats []*chat) {
+	count := len(chats)
+
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 {
+		limit = 100
+	}
+	if offset > len(chats) {
+		offset = len(chats)
+	}
+	chats = chats[offset:]
+	if limit < len(chats) {
+		chats = chats[:limit]
+	}
+
+	writeResult(w, map[string]interface{}{
+		"objects": renderChats(chats),
+		"count":   count,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+func (s *Server) sendMessage(w http.ResponseWriter, r *http.Request, t *team, chatJid string) {
+	if t.chatType(chatJid) == "" {
+		writeError(w, http.StatusNotFound, "NotFound", map[string]string{"chat": chatJid})
+		return
+	}
+
+	mediatype, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediatype == "multipart/form-data" {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidData", map[string]string{"file": err.Error()})
+			return
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(file)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidData", map[string]string{"file": err.Error()})
+			return
+		}
+
+		u := &upload{
+			uid:         uuid.New().String(),
+			name:        header.Filename,
+			contentType: header.Header.Get("Content-Type"),
+			data:        data,
+		}
+		t.uploads[u.uid] = u
+
+		m := t.addMessage(chatJid, r.FormValue("message_id"), "file", header.Filename)
+		m.upload = u
+		s.broadcastMessage(t, m)
+		writeResult(w, s.renderMessage(m))
+		return
+	}
+
+	req := new(messageRequest)
+	if !readJSON(w, r, req) {
+		return
+	}
+	if req.Text == "" && len(req.LinkedMessages) == 0 {
+		writeError(w, http.StatusBadRequest, "InvalidData", map[string]string{"text": "required"})
+		return
+	}
+	if req.Type == "" {
+		req.Type = "plain"
+	}
+
+	var replyTo *message
+	if req.ReplyTo != "" {
+		if replyTo = t.message(req.ReplyTo); replyTo == nil || replyTo.chat != chatJid {
+			writeError(w, http.StatusBadRequest, "InvalidData", map[string]string{"reply_to": "message not found"})
+			return
+		}
+	}
+
+	var linked []*message
+	for _, uid := range req.LinkedMessages {
+		lm := t.message(uid)
+		if lm == nil || lm.deleted {
+			writeError(w, http.StatusBadRequest, "InvalidData", map[string]string{"linked_messages": "message not found: " + uid})
+			return
+		}
+		linked = append(linked, lm)
+	}
+
+	if m := t.message(req.MessageUid); m != nil {
+		// repeated request
+		writeResult(w, s.renderMessage(m))
+		return
+	}
+
+	m := t.addMessage(chatJid, req.MessageUid, req.Type, req.Text)
+	m.replyTo = replyTo
+	m.linked = linked
+	m.important = req.Important
+	s.broadcastMessage(t, m)
+	writeResult(w, s.renderMessage(m))
+}
+
+type messageRequest struct {
+	Type           string   `json:"type"`
+	Text           string   `json:"text"`
+	MessageUid     string   `json:"message_id"`
+	ReplyTo        string   `json:"reply_to"`
+	LinkedMessages []string `json:"linked_messages"`
+	Important      bool     `json:"important"`
+}
+
+func (s *Server) editMessage(w http.ResponseWriter, r *http.Request, t *team, chatJid, uid string) {
+	m := t.message(uid)
+	if m == nil || m.chat != chatJid || m.deleted {
+		writeError(w, http.StatusNotFound, "NotFound", map[string]string{"message_id": uid})
+		return
+	}
+	if m.from != t.me {
+		writeError(w, http.StatusForbidden, "AccessDenied", map[string]string{"message_id": uid})
+		return
+	}
+
+	req := new(messageRequest)
+	if !readJSON(w, r, req) {
+		return
+	}
+	if req.Text == "" && len(m.linked) == 0 {
+		writeError(w, http.StatusBadRequest, "InvalidData", map[string]string{"text": "required"})
+		return
+	}
+
+	m.text = req.Text
+	m.important = req.Important
+	m.edited = time.Now()
+	m.gentime = gentime()
+	s.broadcastMessage(t, m)
+	writeResult(w, s.renderMessage(m))
+}
+
+func (s *Server) deleteMessage(w http.ResponseWriter, t *team, chatJid, uid string) {
+	m := t.message(uid)
+	if m == nil || m.chat != chatJid {
+		writeError(w, http.StatusNotFound, "NotFound", map[string]string{"message_id": uid})
+		return
+	}
+	m.deleted = true
+	m.gentime = gentime()
+	s.broadcastMessage(t, m)
+	writeResult(w, map[string]interface{}{
+		"messages": []interface{}{s.renderMessage(m)},
+	})
+}
+
+// getMessages returns chat messages, newest first.
+func (s *Server) getMessages(w http.ResponseWriter, r *http.Request, t *team, chatJid string) {
+	if t.chatType(chatJid) == "" {
+		writeError(w, http.StatusNotFound, "NotFound", map[string]string{"chat": chatJid})
+		return
+	}
+
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 {
+		limit = 200
+	}
+
+	var bound func(m *message) bool
+	if uid := q.Get("old_from"); uid != "" {
+		if from := t.message(uid); from != nil {
+			bound = func(m *message) bool { return m.gentime < from.gentime }
+		}
+	}
+	if uid := q.Get("new_from"); uid != "" {
+		if from := t.message(uid); from != nil {
+			bound = func(m *message) bool { return m.gentime > from.gentime }
+		}
+	}
+	dateFrom := parseDate(q.Get("date_from"))
+	dateTo := parseDate(q.Get("date_to"))
+
+	var res []*message
+	for _, m := range newestFirst(t.messages[chatJid]) {
+		if m.deleted {
+			continue
+		}
+		if bound != nil && !bound(m) {
+			continue
+		}
+		if mediatype := q.Get("type"); mediatype != "" && m.contentType != mediatype {
+			continue
+		}
+		if !dateFrom.IsZero() && m.created.Before(dateFrom) {
+			continue
+		}
+		if !dateTo.IsZero() && m.created.After(dateTo) {
+			continue
+		}
+		res = append(res, m)
+	}
+
+	// new_from pages go from the oldest side, like on real server
+	if q.Get("new_from") != "" && len(res) > limit {
+		res = res[len(res)-limit:]
+	} else if len(res) > limit {
+		res = res[:limit]
+	}
+
+	writeResult(w, map[string]interface{}{
+		"messages": s.renderMessages(res),
+	})
+}
+
+func parseDate(v string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.000000Z0700", "2006-01-02"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+type taskRequest struct {
+	Description *string   `json:"description"`
+	Tags        *[]string `json:"tags"`
+	Assignee    *string   `json:"assignee"`
+	Observers   *[]string `json:"observers"`
+	Deadline    *string   `json:"deadline"`
+	Public      *bool     `json:"public"`
+	TaskStatus  *string   `json:"task_status"`
+	Items       []string  `json:"items"`
+}
+
+func (req taskRequest) apply(c *chat) {
+	if req.Description != nil {
+		c.description = *req.Description
+		c.displayName = *req.Description
+	}
+	if req.Tags != nil {
+		c.tags = *req.Tags
+	}
+	if req.Assignee != nil {
+		c.assignee = *req.Assignee
+	}
+	if req.Observers != nil {
+		c.observers = *req.Observers
+	}
+	if req.Deadline != nil {
+		c.deadline = *req.Deadline
+	}
+	if req.Public != nil {
+		c.public = *req.Public
+	}
+	if req.TaskStatus != nil {
+		c.taskStatus = *req.TaskStatus
+	}
+	c.gentime = gentime()
+}
+
+func (s *Server) createTask(w http.ResponseWriter, r *http.Request, t *team) {
+	req := new(taskRequest)
+	if !readJSON(w, r, req) {
+		return
+	}
+	if req.Description == nil || *req.Description == "" {
+		writeError(w, http.StatusBadRequest, "InvalidData", map[string]string{"description": "required"})
+		return
+	}
+	if req.Assignee != nil && *req.Assignee != "" && t.contact(*req.Assignee) == nil {
+		writeError(w, http.StatusBadRequest, "InvalidData", map[string]string{"assignee": "contact not found"})
+		return
+	}
+
+	c := t.addChat(taskChatType, "")
+	c.taskStatus = "new"
+	c.owner = t.me
+	c.members = []*member{{jid: t.me, status: "admin"}}
+	req.apply(c)
+	for _, text := range req.Items {
+		t.addTaskItem(c, text)
+	}
+	writeResult(w, renderChat(c))
+}
+
+func (s *Server) updateTask(w http.ResponseWriter, r *http.Request, c *chat) {
+	req := new(taskRequest)
+	if !readJSON(w, r, req) {
+		return
+	}
+	req.apply(c)
+	writeResult(w, renderChat(c))
+}
+
+func (s *Server) addTaskItem(w http.ResponseWriter, r *http.Request, t *team, c *chat) {
+	req := new(struct {
+		Text string `json:"text"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		writeError(w, http.StatusBadRequest, "InvalidData", map[string]string{"text": "required"})
+		return
+	}
+	writeResult(w, renderTaskItem(t.addTaskItem(c, req.Text)))
+}
+
+func (s *Server) updateTaskItem(w http.ResponseWriter, r *http.Request, c *chat, uid string) {
+	item := c.item(uid)
+	if item == nil {
+		writeError(w, http.StatusNotFound, "NotFound", map[string]string{"item": uid})
+		return
+	}
+	req := new(struct {
+		Text         *string `json:"text"`
+		Checked      *bool   `json:"checked"`
+		SortOrdering *int    `json:"sort_ordering"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	if req.Text != nil {
+		item.text = *req.Text
+	}
+	if req.Checked != nil {
+		item.checked = *req.Checked
+	}
+	if req.SortOrdering != nil {
+		// shift following items, like drag and drop in ui
+		for _, other := range c.items {
+			if other != item && other.sortOrdering >= *req.SortOrdering {
+				other.sortOrdering++
+			}
+		}
+		item.sortOrdering = *req.SortOrdering
+	}
+	item.gentime = gentime()
+	c.gentime = gentime()
+	writeResult(w, renderTaskItem(item))
+}
+
+func (s *Server) createGroup(w http.ResponseWriter, r *http.Request, t *team) {
+	req := new(struct {
+		DisplayName string `json:"display_name"`
+		Public      bool   `json:"public"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	if req.DisplayName == "" {
+		writeError(w, http.StatusBadRequest, "InvalidData", map[string]string{"display_name": "required"})
+		return
+	}
+
+	c := t.addChat(groupChatType, req.DisplayName)
+	c.public = req.Public
+	c.members = []*member{{jid: t.me, status: "admin"}}
+	writeResult(w, renderChat(c))
+}
+
+func (s *Server) addGroupMember(w http.ResponseWriter, r *http.Request, t *team, c *chat) {
+	req := new(struct {
+		Jid    string `json:"jid"`
+		Status string `json:"status"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	if t.contact(req.Jid) == nil {
+		writeError(w, http.StatusBadRequest, "InvalidData", map[string]string{"jid": "contact not found"})
+		return
+	}
+	if req.Status == "" {
+		req.Status = "member"
+	}
+
+	for _, m := range c.members {
+		if m.jid == req.Jid {
+			m.status = req.Status
+			writeResult(w, renderMember(m))
+			return
+		}
+	}
+
+	m := &member{jid: req.Jid, status: req.Status}
+	c.members = append(c.members, m)
+	writeResult(w, renderMember(m))
+}
+
+func (s *Server) updateGroup(w http.ResponseWriter, r *http.Request, c *chat) {
+	req := new(struct {
+		DisplayName        *string `json:"display_name"`
+		Description        *string `json:"description"`
+		Public             *bool   `json:"public"`
+		ReadonlyForMembers *bool   `json:"readonly_for_members"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	if req.DisplayName != nil {
+		if strings.TrimSpace(*req.DisplayName) == "" {
+			writeError(w, http.StatusBadRequest, "InvalidData", map[string]string{"display_name": "required"})
+			return
+		}
+		c.displayName = *req.DisplayName
+	}
+	if req.Description != nil {
+		c.description = *req.Description
+	}
+	if req.Public != nil {
+		c.public = *req.Public
+	}
+	if req.ReadonlyForMembers != nil {
+		c.readonly = *req.ReadonlyForMembers
+	}
+	c.gentime = gentime()
+	writeResult(w, renderChat(c))
+}
+
+// updateGroupMember changes member status. Group always keeps at least one admin.
+func (s *Server) updateGroupMember(w http.ResponseWriter, r *http.Request, c *chat, jid string) {
+	req := new(struct {
+		Status string `json:"status"`
+	})
+	if !readJSON(w, r, req) {
+		return
+	}
+	if req.Status != "admin" && req.Status != "member" {
+		writeError(w, http.StatusBadRequest, "InvalidData", map[string]string{"status": "invalid"})
+		return
+	}
+
+	var target *member
+	admins := 0
+	for _, m := range c.members {
+		if m.jid == jid {
+			target = m
+		}
+		if m.status == "admin" {
+			admins++
+		}
+	}
+	if target == nil {
+		writeError(w, http.StatusNotFound, "NotFound", map[string]string{"member": jid})
+		return
+	}
+	if target.status == "admin" && req.Status != "admin" && admins == 1 {
+		writeError(w, http.StatusForbidden, "AccessDenied", map[string]string{"status": "last admin"})
+		return
+	}
+
+	target.status = req.Status
+	c.gentime = gentime()
+	writeResult(w, renderMember(target))
+}
+
+// serveUpload serves uploaded file content, with range requests support.
+func (s *Server) serveUpload(w http.ResponseWriter, r *http.Request) {
+	uid := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/uploads/"), "/", 2)[0]
+
+	s.mu.Lock()
+	var u *upload
+	for _, t := range s.teams {
+		if v, ok := t.uploads[uid]; ok {
+			u = v
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if u == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	sum := sha256.Sum256(u.data)
+	w.Header().Set("Digest", "sha-256="+base64.StdEncoding.EncodeToString(sum[:]))
+	if u.contentType != "" {
+		w.Header().Set("Content-Type", u.contentType)
+	}
+	http.ServeContent(w, r, path.Base(u.name), time.Time{}, bytes.NewReader(u.data))
+}
@@ -0,0 +1,210 @@
+package tdclienttest
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Objects are rendered as maps with tdproto json field names.
+
+func (s *Server) renderTeam(t *team) map[string]interface{} {
+	v := make(map[string]interface{}, len(t.settings)+4)
+	for k, setting := range t.settings {
+		v[k] = setting
+	}
+	v["uid"] = t.uid
+	v["name"] = t.name
+	v["me"] = renderContact(t.contact(t.me))
+	v["gentime"] = gentime()
+	return v
+}
+
+func (s *Server) renderMe() map[string]interface{} {
+	teams := make([]interface{}, 0, len(s.order))
+	for _, uid := range s.order {
+		teams = append(teams, s.renderTeam(s.teams[uid]))
+	}
+	return map[string]interface{}{
+		"display_name": s.Account.DisplayName,
+		"phone":        s.Account.Phone,
+		"teams":        teams,
+	}
+}
+
+func renderContact(c *contact) map[string]interface{} {
+	v := map[string]interface{}{
+		"jid":             c.jid,
+		"display_name":    c.displayName,
+		"contact_phone":   c.phone,
+		"contact_email":   c.email,
+		"role":            c.role,
+		"status":          c.status,
+		"is_archive":      c.isArchive,
+		"can_add_to_team": c.canAddToTeam,
+		"gentime":         gentime(),
+	}
+	if len(c.customFields) > 0 {
+		v["custom_fields"] = c.customFields
+	}
+	return v
+}
+
+func renderContacts(contacts []*contact) []interface{} {
+	res := make([]interface{}, 0, len(contacts))
+	for _, c := range contacts {
+		res = append(res, renderContact(c))
+	}
+	return res
+}
+
+func renderChat(c *chat) map[string]interface{} {
+	v := map[string]interface{}{
+		"jid":          c.jid,
+		"chat_type":    c.chatType,
+		"display_name": c.displayName,
+		"description":  c.description,
+		"public":       c.public,
+		"gentime":      c.gentime,
+	}
+	if !c.created.IsZero() {
+		v["created"] = isoDatetime(c.created)
+	}
+	if c.chatType == groupChatType {
+		v["readonly_for_members"] = c.readonly
+		members := make([]interface{}, 0, len(c.members))
+		for _, m := range c.members {
+			members = append(members, renderMember(m))
+		}
+		v["members"] = members
+	}
+	if c.chatType == taskChatType {
+		v["num"] = c.num
+		v["task_status"] = c.taskStatus
+		v["owner"] = c.owner
+		v["assignee"] = c.assignee
+		v["observers"] = c.observers
+		v["deadline"] = c.deadline
+		v["tags"] = c.tags
+		v["items"] = renderTaskItems(c.items)
+		parents := make([]interface{}, 0, len(c.parents))
+		for _, p := range c.parents {
+			parents = append(parents, renderSubtask(p))
+		}
+		v["parents"] = parents
+	}
+	return v
+}
+
+// renderTaskItems renders checklist ordered by sort ordering.
+func renderTaskItems(items []*taskItem) []interface{} {
+	sorted := append([]*taskItem(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].sortOrdering < sorted[j].sortOrdering })
+	res := make([]interface{}, 0, len(sorted))
+	for _, item := range sorted {
+		res = append(res, renderTaskItem(item))
+	}
+	return res
+}
+
+func renderTaskItem(item *taskItem) map[string]interface{} {
+	v := map[string]interface{}{
+		"uid":           item.uid,
+		"text":          item.text,
+		"checked":       item.checked,
+		"sort_ordering": item.sortOrdering,
+		"can_toggle":    true,
+		"can_change":    true,
+		"gentime":       item.gentime,
+	}
+	if item.subtask != nil {
+		v["subtask"] = renderSubtask(item.subtask)
+	}
+	return v
+}
+
+func renderSubtask(c *chat) map[string]interface{} {
+	return map[string]interface{}{
+		"jid":          c.jid,
+		"assignee":     c.assignee,
+		"title":        fmt.Sprintf("#%d %s", c.num, c.displayName),
+		"num":          c.num,
+		"display_name": c.displayName,
+		"public":       c.public,
+		"task_status":  c.taskStatus,
+	}
+}
+
+func renderChats(chats []*chat) []interface{} {
+	res := make([]interface{}, 0, len(chats))
+	for _, c := range chats {
+		res = append(res, renderChat(c))
+	}
+	return res
+}
+
+func renderMember(m *member) map[string]interface{} {
+	return map[string]interface{}{
+		"jid":    m.jid,
+		"status": m.status,
+	}
+}
+
+func (s *Server) renderMessage(m *message) map[string]interface{} {
+	v := map[string]interface{}{
+		"message_id": m.uid,
+		"chat":       m.chat,
+		"chat_type":  m.chatType,
+		"from":       m.from,
+		"to":         m.to,
+		"content": map[string]interface{}{
+			"type": m.contentType,
+			"text": m.text,
+		},
+		"push_text":  m.text,
+		"created":    isoDatetime(m.created),
+		"gentime":    m.gentime,
+		"is_deleted": m.deleted,
+	}
+	if m.important {
+		v["important"] = true
+	}
+	if !m.edited.IsZero() {
+		v["edited"] = isoDatetime(m.edited)
+	}
+	if m.replyTo != nil {
+		v["reply_to"] = s.renderMessage(m.replyTo)
+	}
+	if len(m.linked) > 0 {
+		v["linked_messages"] = s.renderMessages(m.linked)
+	}
+	if m.upload != nil {
+		u := s.renderUpload(m.upload)
+		v["uploads"] = []interface{}{u}
+		v["links"] = []interface{}{
+			map[string]interface{}{
+				"pattern": u["url"],
+				"url":     u["url"],
+				"text":    m.upload.name,
+			},
+		}
+	}
+	return v
+}
+
+func (s *Server) renderMessages(messages []*message) []interface{} {
+	res := make([]interface{}, 0, len(messages))
+	for _, m := range messages {
+		res = append(res, s.renderMessage(m))
+	}
+	return res
+}
+
+func (s *Server) renderUpload(u *upload) map[string]interface{} {
+	return map[string]interface{}{
+		"uid":          u.uid,
+		"name":         u.name,
+		"size":         len(u.data),
+		"content_type": u.contentType,
+		"url":          s.URL + "/uploads/" + u.uid + "/" + u.name,
+	}
+}
@@ -0,0 +1,269 @@
+// Package tdclienttest provides in-process fake Tada server for hermetic tests.
+//
+//	srv := tdclienttest.NewServer()
+//	defer srv.Close()
+//
+//	session, _ := tdclient.NewSession(srv.URL)
+//	session.SetToken(srv.Account.Token)
+//
+// Server keeps teams, contacts, chats, messages and tasks in memory, implements REST api
+// used by tdclient and /messaging/{team} websocket. Failures and server events can be injected.
+package tdclienttest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Account is the only user of fake server.
+type Account struct {
+	Phone       string
+	Code        string
+	Username    string
+	Password    string
+	Token       string
+	DisplayName string
+}
+
+// Failure is injected error response. Zero Times means once.
+type Failure struct {
+	Method string // any method if empty
+	Path   string // path prefix, like "/api/v4/teams/"
+	Status int
+	Body   string // default is tdapi error response
+	Header http.Header
+	Times  int
+}
+
+type Server struct {
+	URL     string
+	Account Account
+
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	teams    map[string]*team
+	order    []string
+	failures []*Failure
+	conns    map[*wsConn]struct{}
+	confirms map[string]bool
+	requests []string
+}
+
+// NewServer starts fake server with one team and default account.
+func NewServer() *Server {
+	s := &Server{
+		Account: Account{
+			Phone:       "+70000000000",
+			Code:        "1234",
+			Username:    "user",
+			Password:    "password",
+			Token:       newToken(),
+			DisplayName: "Test User",
+		},
+		teams:    make(map[string]*team),
+		conns:    make(map[*wsConn]struct{}),
+		confirms: make(map[string]bool),
+	}
+	s.AddTeam("test team")
+	s.srv = httptest.NewServer(s)
+	s.URL = s.srv.URL
+	return s
+}
+
+func (s *Server) Close() {
+	s.mu.Lock()
+	for c := range s.conns {
+		c.close()
+	}
+	s.mu.Unlock()
+	s.srv.Close()
+}
+
+// AddTeam creates team and returns its uid.
+func (s *Server) AddTeam(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addTeam(name).uid
+}
+
+// SetTeamStatus changes account status in team: "owner", "admin", "member" or "guest".
+// Use it to check how client behaves without admin rights.
+func (s *Server) SetTeamStatus(teamUid, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t := s.teams[teamUid]; t != nil {
+		t.contact(t.me).status = status
+	}
+}
+
+// Teams returns uids of all teams, in creation order.
+func (s *Server) Teams() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.order...)
+}
+
+// Fail injects error response for matching requests.
+func (s *Server) Fail(f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f.Times <= 0 {
+		f.Times = 1
+	}
+	if f.Status == 0 {
+		f.Status = http.StatusInternalServerError
+	}
+	s.failures = append(s.failures, &f)
+}
+
+// Requests returns "METHOD /path" of all handled requests.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// RevokeToken makes current account token invalid, like server-side logout.
+func (s *Server) RevokeToken() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Account.Token = ""
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	f := s.popFailure(r)
+	s.mu.Unlock()
+
+	if f != nil {
+		for k, v := range f.Header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(f.Status)
+		if f.Body != "" {
+			_, _ = w.Write([]byte(f.Body))
+		} else {
+			_ = json.NewEncoder(w).Encode(errorResp(http.StatusText(f.Status), nil))
+		}
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/features.json":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"build":              "tdclienttest",
+			"max_message_length": 16384,
+		})
+	case r.URL.Path == "/api/v4/ping":
+		writeResult(w, "pong")
+	case strings.HasPrefix(r.URL.Path, "/api/v4/auth/"):
+		s.serveAuth(w, r)
+	case strings.HasPrefix(r.URL.Path, "/uploads/"):
+		if !s.authorized(r) {
+			writeError(w, http.StatusUnauthorized, "InvalidToken", nil)
+			return
+		}
+		s.serveUpload(w, r)
+	case strings.HasPrefix(r.URL.Path, "/messaging/"):
+		if !s.authorized(r) {
+			writeError(w, http.StatusUnauthorized, "InvalidToken", nil)
+			return
+		}
+		s.serveWs(w, r, strings.TrimPrefix(r.URL.Path, "/messaging/"))
+	case strings.HasPrefix(r.URL.Path, "/api/v4/teams"):
+		if !s.authorized(r) {
+			writeError(w, http.StatusUnauthorized, "InvalidToken", nil)
+			return
+		}
+		s.serveTeams(w, r)
+	default:
+		writeError(w, http.StatusNotFound, "NotFound", nil)
+	}
+}
+
+func (s *Server) popFailure(r *http.Request) *Failure {
+	for i, f := range s.failures {
+		if f.Method != "" && f.Method != r.Method {
+			continue
+		}
+		if !strings.HasPrefix(r.URL.Path, f.Path) {
+			continue
+		}
+		f.Times--
+		if f.Times <= 0 {
+			s.failures = append(s.failures[:i], s.failures[i+1:]...)
+		}
+		return f
+	}
+	return nil
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token := r.Header.Get("token")
+	if token == "" {
+		if c, err := r.Cookie("token"); err == nil {
+			token = c.Value
+		}
+	}
+
+	return token != "" && token == s.Account.Token
+}
+
+func newToken() string {
+	return strings.ReplaceAll(uuid.New().String(), "-", "")
+}
+
+func newJID(prefix string) string {
+	return prefix + "-" + uuid.New().String()
+}
+
+func isoDatetime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000000Z")
+}
+
+func errorResp(code string, details map[string]string) map[string]interface{} {
+	resp := map[string]interface{}{
+		"ok":    false,
+		"error": code,
+	}
+	if len(details) > 0 {
+		resp["details"] = details
+	}
+	return resp
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeResult(w http.ResponseWriter, result interface{}) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"ok":     true,
+		"result": result,
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, code string, details map[string]string) {
+	writeJSON(w, status, errorResp(code, details))
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", map[string]string{"body": err.Error()})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package tdclienttest_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/tada-team/tdclient"
+	"github.com/tada-team/tdclient/tdclienttest"
+	"github.com/tada-team/tdproto"
+)
+
+func TestServer(t *testing.T) {
+	srv := tdclienttest.NewServer()
+	defer srv.Close()
+
+	s, err := tdclient.NewSession(srv.URL, tdclient.WithLogger(tdclient.NopLogger{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetToken(srv.Account.Token)
+
+	teamUid := srv.Teams()[0]
+
+	t.Run("failure injection", func(t *testing.T) {
+		srv.Fail(tdclienttest.Failure{
+			Method: http.MethodGet,
+			Path:   "/api/v4/teams/",
+			Status: http.StatusNotFound,
+		})
+
+		if _, err := s.Contacts(teamUid); !tdclient.IsNotFound(err) {
+			t.Fatal("want not found, got:", err)
+		}
+		if _, err := s.Contacts(teamUid); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("server event", func(t *testing.T) {
+		ws, err := s.Ws(teamUid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ws.Close()
+
+		done := make(chan error, 1)
+		go func() {
+			done <- ws.WaitFor(new(tdproto.ServerWarning))
+		}()
+
+		// listener may be not ready yet, so repeat event until it is received
+		var confirmId string
+	loop:
+		for i := 0; i < 100; i++ {
+			if id, err := srv.SendEvent(teamUid, map[string]interface{}{
+				"event":  "server.warning",
+				"params": map[string]string{"message": "test"},
+			}); err == nil {
+				confirmId = id
+			}
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatal(err)
+				}
+				break loop
+			case <-time.After(20 * time.Millisecond):
+			}
+		}
+
+		for i := 0; i < 100 && !srv.Confirmed(confirmId); i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if !srv.Confirmed(confirmId) {
+			t.Error("event not confirmed:", confirmId)
+		}
+	})
+
+	t.Run("revoked token", func(t *testing.T) {
+		srv.RevokeToken()
+		if _, err := s.Me(teamUid); !tdclient.IsUnauthorized(err) {
+			t.Fatal("want unauthorized, got:", err)
+		}
+	})
+}
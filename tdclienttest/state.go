@@ -0,0 +1,330 @@
+package tdclienttest
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	directChatType = "direct"
+	groupChatType  = "group"
+	taskChatType   = "task"
+)
+
+type team struct {
+	uid      string
+	name     string
+	settings map[string]interface{}
+	me       string
+	contacts []*contact
+	chats    []*chat
+	lastNum  int
+	messages map[string][]*message
+	uploads  map[string]*upload
+}
+
+type contact struct {
+	jid          string
+	displayName  string
+	phone        string
+	email        string
+	role         string
+	status       string
+	isArchive    bool
+	customFields map[string]interface{}
+	canAddToTeam bool
+}
+
+type chat struct {
+	jid         string
+	chatType    string
+	displayName string
+	description string
+	public      bool
+	readonly    bool
+	num         int
+	taskStatus  string
+	owner       string
+	assignee    string
+	observers   []string
+	items       []*taskItem
+	parents     []*chat
+	deadline    string
+	tags        []string
+	members     []*member
+	created     time.Time
+	gentime     int64
+}
+
+type taskItem struct {
+	uid          string
+	text         string
+	checked      bool
+	sortOrdering int
+	subtask      *chat
+	gentime      int64
+}
+
+type member struct {
+	jid    string
+	status string
+}
+
+type message struct {
+	uid         string
+	chat        string
+	chatType    string
+	from        string
+	to          string
+	contentType string
+	text        string
+	upload      *upload
+	replyTo     *message
+	linked      []*message
+	important   bool
+	deleted     bool
+	created     time.Time
+	edited      time.Time
+	gentime     int64
+}
+
+type upload struct {
+	uid         string
+	name        string
+	contentType string
+	data        []byte
+}
+
+var lastGentime int64
+
+// gentime returns strictly increasing nanosecond timestamps.
+func gentime() int64 {
+	for {
+		last := atomic.LoadInt64(&lastGentime)
+		v := time.Now().UnixNano()
+		if v <= last {
+			v = last + 1
+		}
+		if atomic.CompareAndSwapInt64(&lastGentime, last, v) {
+			return v
+		}
+	}
+}
+
+func (s *Server) addTeam(name string) *team {
+	t := &team{
+		uid:      uuid.New().String(),
+		name:     name,
+		settings: make(map[string]interface{}),
+		messages: make(map[string][]*message),
+		uploads:  make(map[string]*upload),
+	}
+
+	me := t.addContact(s.Account.DisplayName, s.Account.Phone)
+	me.status = "owner"
+	me.canAddToTeam = true
+	t.me = me.jid
+
+	t.addContact("TadaBot", "")
+	t.addContact("Support", "")
+
+	s.teams[t.uid] = t
+	s.order = append(s.order, t.uid)
+	return t
+}
+
+func (s *Server) dropTeam(uid string) {
+	delete(s.teams, uid)
+	for i, v := range s.order {
+		if v == uid {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			return
+		}
+	}
+}
+
+func (t *team) addContact(displayName, phone string) *contact {
+	c := &contact{
+		jid:         newJID("d"),
+		displayName: displayName,
+		phone:       phone,
+		status:      "member",
+	}
+	t.contacts = append(t.contacts, c)
+	return c
+}
+
+func (t *team) contact(jid string) *contact {
+	for _, c := range t.contacts {
+		if c.jid == jid {
+			return c
+		}
+	}
+	return nil
+}
+
+func (t *team) dropContact(jid string) bool {
+	for i, c := range t.contacts {
+		if c.jid == jid {
+			t.contacts = append(t.contacts[:i], t.contacts[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *team) addChat(chatType, displayName string) *chat {
+	prefix := "g"
+	if chatType == taskChatType {
+		prefix = "t"
+	}
+	c := &chat{
+		jid:         newJID(prefix),
+		chatType:    chatType,
+		displayName: displayName,
+		created:     time.Now(),
+		gentime:     gentime(),
+	}
+	if chatType == taskChatType {
+		t.lastNum++
+		c.num = t.lastNum
+	}
+	t.chats = append(t.chats, c)
+	return c
+}
+
+// addTaskItem appends checklist item. Text "#{num}" links task with that number as subtask.
+func (t *team) addTaskItem(c *chat, text string) *taskItem {
+	item := &taskItem{
+		uid:          uuid.New().String(),
+		text:         text,
+		sortOrdering: len(c.items) + 1,
+		gentime:      gentime(),
+	}
+	if strings.HasPrefix(text, "#") {
+		num, _ := strconv.Atoi(text[1:])
+		for _, sub := range t.chats {
+			if sub.chatType == taskChatType && sub.num == num && sub != c {
+				item.subtask = sub
+				sub.parents = append(sub.parents, c)
+				break
+			}
+		}
+	}
+	c.items = append(c.items, item)
+	c.gentime = gentime()
+	return item
+}
+
+func (c *chat) item(uid string) *taskItem {
+	for _, item := range c.items {
+		if item.uid == uid {
+			return item
+		}
+	}
+	return nil
+}
+
+func (t *team) chat(jid string) *chat {
+	for _, c := range t.chats {
+		if c.jid == jid {
+			return c
+		}
+	}
+	return nil
+}
+
+func (t *team) dropChat(jid string) bool {
+	for i, c := range t.chats {
+		if c.jid == jid {
+			t.chats = append(t.chats[:i], t.chats[i+1:]...)
+			delete(t.messages, jid)
+			return true
+		}
+	}
+	return false
+}
+
+// chatType returns type of chat or direct chat with contact, empty if not found.
+func (t *team) chatType(jid string) string {
+	if t.contact(jid) != nil {
+		return directChatType
+	}
+	if c := t.chat(jid); c != nil {
+		return c.chatType
+	}
+	return ""
+}
+
+func (t *team) addMessage(chatJid, uid, contentType, text string) *message {
+	for _, m := range t.messages[chatJid] {
+		if m.uid == uid {
+			return m
+		}
+	}
+	if uid == "" {
+		uid = uuid.New().String()
+	}
+	m := &message{
+		uid:         uid,
+		chat:        chatJid,
+		chatType:    t.chatType(chatJid),
+		from:        t.me,
+		to:          chatJid,
+		contentType: contentType,
+		text:        text,
+		created:     time.Now(),
+		gentime:     gentime(),
+	}
+	t.messages[chatJid] = append(t.messages[chatJid], m)
+	return m
+}
+
+func (t *team) message(uid string) *message {
+	for _, messages := range t.messages {
+		for _, m := range messages {
+			if m.uid == uid {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+// directChats returns direct chats with all team contacts.
+func (t *team) directChats() []*chat {
+	chats := make([]*chat, 0, len(t.contacts))
+	for _, c := range t.contacts {
+		chats = append(chats, &chat{
+			jid:         c.jid,
+			chatType:    directChatType,
+			displayName: c.displayName,
+		})
+	}
+	return chats
+}
+
+func (t *team) chatsByType(chatType string) []*chat {
+	var chats []*chat
+	if chatType == "" || chatType == directChatType {
+		chats = append(chats, t.directChats()...)
+	}
+	for _, c := range t.chats {
+		if chatType == "" || c.chatType == chatType {
+			chats = append(chats, c)
+		}
+	}
+	return chats
+}
+
+// newestFirst returns copy of messages sorted by gentime, newest first.
+func newestFirst(messages []*message) []*message {
+	res := append([]*message(nil), messages...)
+	sort.Slice(res, func(i, j int) bool { return res[i].gentime > res[j].gentime })
+	return res
+}
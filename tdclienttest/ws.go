@@ -0,0 +1,264 @@
+package tdclienttest
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+const wsWriteTimeout = 5 * time.Second
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+type wsConn struct {
+	team    string
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+}
+
+func (c *wsConn) write(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if err := c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout)); err != nil {
+		return err
+	}
+	return c.conn.WriteMessage(websocket.BinaryMessage, b)
+}
+
+func (c *wsConn) close() {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = c.conn.Close()
+}
+
+type clientEvent struct {
+	Event     string          `json:"event"`
+	ConfirmId string          `json:"confirm_id"`
+	Params    json.RawMessage `json:"params"`
+}
+
+// SendEvent pushes server event to every websocket of the team and returns its confirm id.
+// Event is any json-serializable value, like tdproto.ServerWarning. Use Confirmed to check client confirmation.
+func (s *Server) SendEvent(teamUid string, event interface{}) (string, error) {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return "", errors.Wrap(err, "json marshal fail")
+	}
+
+	var v map[string]interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return "", errors.Wrap(err, "event must be json object")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	confirmId := s.sendToTeam(teamUid, v)
+	if confirmId == "" {
+		return "", errors.Errorf("no websocket connections for team %s", teamUid)
+	}
+	return confirmId, nil
+}
+
+// Confirmed reports whether client confirmed event with given confirm id.
+func (s *Server) Confirmed(confirmId string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.confirms[confirmId]
+}
+
+// DropConnections closes all websocket connections, like server restart.
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.conns {
+		c.close()
+		delete(s.conns, c)
+	}
+}
+
+// sendToTeam sets new confirm id to event and writes it to team connections. Called under lock.
+// Returns empty string if nothing was sent.
+func (s *Server) sendToTeam(teamUid string, event map[string]interface{}) string {
+	confirmId := uuid.New().String()
+	event["confirm_id"] = confirmId
+
+	sent := false
+	for c := range s.conns {
+		if c.team != teamUid {
+			continue
+		}
+		if err := c.write(event); err == nil {
+			sent = true
+		}
+	}
+	if !sent {
+		return ""
+	}
+
+	s.confirms[confirmId] = false
+	return confirmId
+}
+
+// broadcastMessage sends server.message.updated for the message. Called under lock.
+func (s *Server) broadcastMessage(t *team, m *message) {
+	s.sendToTeam(t.uid, map[string]interface{}{
+		"event": "server.message.updated",
+		"params": map[string]interface{}{
+			"messages": []interface{}{s.renderMessage(m)},
+			"delayed":  false,
+		},
+	})
+}
+
+func (s *Server) serveWs(w http.ResponseWriter, r *http.Request, teamUid string) {
+	s.mu.Lock()
+	_, ok := s.teams[teamUid]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "NotFound", map[string]string{"team": teamUid})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &wsConn{team: teamUid, conn: conn}
+
+	s.mu.Lock()
+	s.conns[c] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		c.close()
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		ev := new(clientEvent)
+		if err := json.Unmarshal(data, ev); err != nil {
+			_ = c.write(serverWarning("invalid json: " + err.Error()))
+			continue
+		}
+
+		s.handleWsEvent(c, ev)
+	}
+}
+
+func (s *Server) handleWsEvent(c *wsConn, ev *clientEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.teams[c.team]
+	if t == nil {
+		return
+	}
+
+	switch ev.Event {
+	case "client.ping":
+		_ = c.write(serverConfirm(ev.ConfirmId))
+
+	case "client.confirm":
+		params := new(struct {
+			ConfirmId string `json:"confirm_id"`
+		})
+		_ = json.Unmarshal(ev.Params, params)
+		if params.ConfirmId == "" {
+			params.ConfirmId = ev.ConfirmId
+		}
+		if _, ok := s.confirms[params.ConfirmId]; ok {
+			s.confirms[params.ConfirmId] = true
+		}
+
+	case "client.message.updated":
+		params := new(struct {
+			MessageId string `json:"message_id"`
+			To        string `json:"to"`
+			Content   struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+		})
+		if err := json.Unmarshal(ev.Params, params); err != nil {
+			_ = c.write(serverWarning("invalid params: " + err.Error()))
+			return
+		}
+		if t.chatType(params.To) == "" {
+			_ = c.write(serverWarning("chat not found: " + params.To))
+			return
+		}
+		if params.Content.Type == "" {
+			params.Content.Type = "plain"
+		}
+
+		m := t.message(params.MessageId)
+		if m != nil {
+			m.text = params.Content.Text
+			m.gentime = gentime()
+		} else {
+			m = t.addMessage(params.To, params.MessageId, params.Content.Type, params.Content.Text)
+		}
+		if ev.ConfirmId != "" {
+			_ = c.write(serverConfirm(ev.ConfirmId))
+		}
+		s.broadcastMessage(t, m)
+
+	case "client.message.deleted":
+		params := new(struct {
+			MessageId string `json:"message_id"`
+		})
+		_ = json.Unmarshal(ev.Params, params)
+
+		m := t.message(params.MessageId)
+		if m == nil {
+			_ = c.write(serverWarning("message not found: " + params.MessageId))
+			return
+		}
+		m.deleted = true
+		m.gentime = gentime()
+		if ev.ConfirmId != "" {
+			_ = c.write(serverConfirm(ev.ConfirmId))
+		}
+		s.broadcastMessage(t, m)
+	}
+}
+
+func serverConfirm(confirmId string) map[string]interface{} {
+	return map[string]interface{}{
+		"event": "server.confirm",
+		"params": map[string]interface{}{
+			"confirm_id": confirmId,
+		},
+	}
+}
+
+func serverWarning(message string) map[string]interface{} {
+	return map[string]interface{}{
+		"event": "server.warning",
+		"params": map[string]interface{}{
+			"message": message,
+		},
+	}
+}
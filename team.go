@@ -0,0 +1,405 @@
+package tdclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/tada-team/tdproto"
+	"github.com/tada-team/tdproto/tdapi"
+)
+
+// TeamClient is session handle bound to one team. Team uid is validated once, in Session.Team,
+// so multi-team services can keep one handle per team.
+type TeamClient struct {
+	session *Session
+	uid     string
+}
+
+// Team returns client for team-level operations. Returns InvalidTeamUid for malformed uid.
+func (s *Session) Team(uid string) (*TeamClient, error) {
+	if !tdproto.ValidUid(uid) {
+		return nil, InvalidTeamUid
+	}
+	return &TeamClient{session: s, uid: uid}, nil
+}
+
+func (t *TeamClient) Uid() string {
+	return t.uid
+}
+
+func (t *TeamClient) Session() *Session {
+	return t.session
+}
+
+func (t *TeamClient) Me() (tdproto.Contact, error) {
+	return t.MeContext(context.Background())
+}
+
+func (t *TeamClient) MeContext(ctx context.Context) (tdproto.Contact, error) {
+	team, err := t.GetTeamContext(ctx)
+	if err != nil {
+		return tdproto.Contact{}, err
+	}
+	return team.Me, nil
+}
+
+func (t *TeamClient) GetTeam() (tdproto.Team, error) {
+	return t.GetTeamContext(context.Background())
+}
+
+func (t *TeamClient) GetTeamContext(ctx context.Context) (tdproto.Team, error) {
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.Team `json:"result"`
+	})
+
+	if err := t.session.doGet(ctx, "/api/v4/teams/"+t.uid, nil, resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+// TeamUpdate is partial team settings edit. Nil fields are not changed.
+type TeamUpdate struct {
+	Name                   *string `json:"name,omitempty"`
+	DefaultTaskDeadline    *string `json:"default_task_deadline,omitempty"`
+	MaxMessageUpdateAge    *int    `json:"max_message_update_age,omitempty"`
+	UsePatronymic          *bool   `json:"use_patronymic,omitempty"`
+	DisplayFamilyNameFirst *bool   `json:"display_family_name_first,omitempty"`
+	UseTaskImportance      *bool   `json:"use_task_importance,omitempty"`
+	TaskImportanceRev      *bool   `json:"task_importance_rev,omitempty"`
+	UseTaskUrgency         *bool   `json:"use_task_urgency,omitempty"`
+	UseTaskComplexity      *bool   `json:"use_task_complexity,omitempty"`
+	UseTaskSpentTime       *bool   `json:"use_task_spent_time,omitempty"`
+	HideArchivedUsers      *bool   `json:"hide_archived_users,omitempty"`
+	Pinned                 *bool   `json:"pinned,omitempty"`
+}
+
+func (t *TeamClient) UpdateTeam(req TeamUpdate) (tdproto.Team, error) {
+	return t.UpdateTeamContext(context.Background(), req)
+}
+
+// UpdateTeamContext changes team settings. Team admin rights are required.
+func (t *TeamClient) UpdateTeamContext(ctx context.Context, req TeamUpdate) (tdproto.Team, error) {
+	if req.Name != nil && strings.TrimSpace(*req.Name) == "" {
+		return tdproto.Team{}, InvalidTeamName
+	}
+
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.Team `json:"result"`
+	})
+
+	if err := t.session.doPut(ctx, "/api/v4/teams/"+t.uid, req, resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+func (t *TeamClient) RenameTeam(name string) (tdproto.Team, error) {
+	return t.RenameTeamContext(context.Background(), name)
+}
+
+func (t *TeamClient) RenameTeamContext(ctx context.Context, name string) (tdproto.Team, error) {
+	return t.UpdateTeamContext(ctx, TeamUpdate{Name: &name})
+}
+
+func (t *TeamClient) LeaveTeam() error {
+	return t.LeaveTeamContext(context.Background())
+}
+
+// LeaveTeamContext removes own contact from team. Team owner can't leave, delete team instead.
+func (t *TeamClient) LeaveTeamContext(ctx context.Context) error {
+	me, err := t.MeContext(ctx)
+	if err != nil {
+		return err
+	}
+	return t.RemoveContactContext(ctx, me.Jid)
+}
+
+func (t *TeamClient) DeleteTeam() (tdproto.Team, error) {
+	return t.DeleteTeamContext(context.Background())
+}
+
+// DeleteTeamContext deletes team with all chats and messages. Team admin rights are required.
+func (t *TeamClient) DeleteTeamContext(ctx context.Context) (tdproto.Team, error) {
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.Team `json:"result"`
+	})
+
+	if err := t.session.doDelete(ctx, "/api/v4/teams/"+t.uid, resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+func (t *TeamClient) Contacts() ([]tdproto.Contact, error) {
+	return t.ContactsContext(context.Background())
+}
+
+func (t *TeamClient) ContactsContext(ctx context.Context) ([]tdproto.Contact, error) {
+	resp := new(struct {
+		tdapi.Resp
+		Result []tdproto.Contact `json:"result"`
+	})
+
+	if err := t.session.doGet(ctx, fmt.Sprintf("/api/v4/teams/%s/contacts/", t.uid), nil, resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+func (t *TeamClient) AddContact(phone string) (tdproto.Contact, error) {
+	return t.AddContactContext(context.Background(), phone)
+}
+
+func (t *TeamClient) AddContactContext(ctx context.Context, phone string) (tdproto.Contact, error) {
+	if !validPhone(phone) {
+		return tdproto.Contact{}, InvalidPhone
+	}
+
+	req := map[string]interface{}{
+		"phone": phone,
+	}
+
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.Contact `json:"result"`
+	})
+
+	if err := t.session.doPost(ctx, fmt.Sprintf("/api/v4/teams/%s/contacts", t.uid), req, resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+func (t *TeamClient) SendPlaintextMessage(chat tdproto.JID, text string) (tdproto.Message, error) {
+	return t.SendPlaintextMessageContext(context.Background(), chat, text)
+}
+
+func (t *TeamClient) SendPlaintextMessageContext(ctx context.Context, chat tdproto.JID, text string) (tdproto.Message, error) {
+	return t.sendPlaintextMessage(ctx, chat, text, uuid.New().String())
+}
+
+// sendPlaintextMessage sends message with given uid. Server ignores repeated message with same uid.
+func (t *TeamClient) sendPlaintextMessage(ctx context.Context, chat tdproto.JID, text, messageUid string) (tdproto.Message, error) {
+	req := new(messageRequest)
+	req.Text = text
+	req.MessageUid = messageUid
+	return t.postMessage(ctx, fmt.Sprintf("/api/v4/teams/%s/chats/%s/messages", t.uid, chat), req)
+}
+
+func (t *TeamClient) SendUploadMessage(chat tdproto.JID, fname string, file io.ReadCloser, opts ...UploadOption) (tdproto.Message, error) {
+	return t.SendUploadMessageContext(context.Background(), chat, fname, file, opts...)
+}
+
+// SendUploadMessageContext streams file to chat. File is closed after upload.
+func (t *TeamClient) SendUploadMessageContext(ctx context.Context, chat tdproto.JID, fname string, file io.ReadCloser, opts ...UploadOption) (tdproto.Message, error) {
+	req := new(tdapi.Message)
+
+	req.MessageUid = uuid.New().String()
+
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.Message `json:"result"`
+	})
+
+	_, err := t.session.uploadFile(ctx, fmt.Sprintf("/api/v4/teams/%s/chats/%s/messages", t.uid, chat), fname, file, resp, opts...)
+	if err != nil {
+		return tdproto.Message{}, errors.Wrap(err, "uploadFile error")
+	}
+
+	return resp.Result, nil
+}
+
+func (t *TeamClient) GetMessages(chat tdproto.JID, f *tdapi.MessageFilter) ([]tdproto.Message, error) {
+	return t.GetMessagesContext(context.Background(), chat, f)
+}
+
+func (t *TeamClient) GetMessagesContext(ctx context.Context, chat tdproto.JID, f *tdapi.MessageFilter) ([]tdproto.Message, error) {
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.ChatMessages `json:"result"`
+	})
+
+	if f == nil {
+		f = new(tdapi.MessageFilter)
+	}
+
+	if err := t.session.doGet(ctx, fmt.Sprintf("/api/v4/teams/%s/messages/%s", t.uid, chat), f, resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Result.Messages, nil
+}
+
+func (t *TeamClient) DeleteMessage(chat tdproto.JID, msgId string) (tdproto.ChatMessages, error) {
+	return t.DeleteMessageContext(context.Background(), chat, msgId)
+}
+
+func (t *TeamClient) DeleteMessageContext(ctx context.Context, chat tdproto.JID, msgId string) (tdproto.ChatMessages, error) {
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.ChatMessages `json:"result"`
+	})
+
+	if err := t.session.doDelete(ctx, fmt.Sprintf("/api/v4/teams/%s/chats/%s/messages/%s", t.uid, chat, msgId), resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+func (t *TeamClient) CreateTask(req tdapi.Task) (tdproto.Chat, error) {
+	return t.CreateTaskContext(context.Background(), req)
+}
+
+func (t *TeamClient) CreateTaskContext(ctx context.Context, req tdapi.Task) (tdproto.Chat, error) {
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.Chat `json:"result"`
+	})
+
+	if err := t.session.doPost(ctx, fmt.Sprintf("/api/v4/teams/%s/tasks", t.uid), req, resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+func (t *TeamClient) CloseTask(taskUid string) (tdproto.Chat, error) {
+	return t.CloseTaskContext(context.Background(), taskUid)
+}
+
+// CloseTaskContext moves task to TaskStatusDone. See also SetTaskStatus and ReopenTask.
+func (t *TeamClient) CloseTaskContext(ctx context.Context, taskUid string) (tdproto.Chat, error) {
+	return t.updateTask(ctx, tdproto.JID(taskUid), map[string]interface{}{"task_status": TaskStatusDone})
+}
+
+func (t *TeamClient) CreateGroup(req tdapi.Group) (tdproto.Chat, error) {
+	return t.CreateGroupContext(context.Background(), req)
+}
+
+func (t *TeamClient) CreateGroupContext(ctx context.Context, req tdapi.Group) (tdproto.Chat, error) {
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.Chat `json:"result"`
+	})
+
+	if err := t.session.doPost(ctx, fmt.Sprintf("/api/v4/teams/%s/groups", t.uid), req, resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+func (t *TeamClient) GetGroups() ([]tdproto.Chat, error) {
+	return t.GetGroupsContext(context.Background())
+}
+
+func (t *TeamClient) GetGroupsContext(ctx context.Context) ([]tdproto.Chat, error) {
+	resp := new(struct {
+		tdapi.Resp
+		Result []tdproto.Chat `json:"result"`
+	})
+
+	if err := t.session.doGet(ctx, fmt.Sprintf("/api/v4/teams/%s/groups", t.uid), nil, resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+func (t *TeamClient) AddGroupMember(group, contact tdproto.JID) (tdproto.GroupMembership, error) {
+	return t.AddGroupMemberContext(context.Background(), group, contact)
+}
+
+func (t *TeamClient) AddGroupMemberContext(ctx context.Context, group, contact tdproto.JID) (tdproto.GroupMembership, error) {
+	req := tdproto.GroupMembership{
+		Jid:    contact,
+		Status: tdproto.GroupMember,
+	}
+
+	resp := new(struct {
+		tdapi.Resp
+		Result tdproto.GroupMembership `json:"result"`
+	})
+
+	if err := t.session.doPost(ctx, fmt.Sprintf("/api/v4/teams/%s/groups/%s/members", t.uid, group), req, resp); err != nil {
+		return resp.Result, err
+	}
+
+	return resp.Result, nil
+}
+
+func (t *TeamClient) GroupMembers(group tdproto.JID) ([]tdproto.GroupMembership, error) {
+	return t.GroupMembersContext(context.Background(), group)
+}
+
+func (t *TeamClient) GroupMembersContext(ctx context.Context, group tdproto.JID) ([]tdproto.GroupMembership, error) {
+	type MembersParams struct {
+		Members []tdproto.GroupMembership `json:"members"`
+	}
+	resp := new(struct {
+		tdapi.Resp
+		Result MembersParams `json:"result"`
+	})
+
+	if err := t.session.doGet(ctx, fmt.Sprintf("/api/v4/teams/%s/groups/%s/members", t.uid, group), nil, resp); err != nil {
+		return resp.Result.Members, err
+	}
+
+	return resp.Result.Members, nil
+}
+
+func (t *TeamClient) DropGroupMember(group, contact tdproto.JID) error {
+	return t.DropGroupMemberContext(context.Background(), group, contact)
+}
+
+func (t *TeamClient) DropGroupMemberContext(ctx context.Context, group, contact tdproto.JID) error {
+	resp := new(tdapi.Resp)
+
+	if err := t.session.doDelete(ctx, fmt.Sprintf("/api/v4/teams/%s/groups/%s/members/%s", t.uid, group, contact), resp); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (t *TeamClient) DropGroup(group tdproto.JID) error {
+	return t.DropGroupContext(context.Background(), group)
+}
+
+func (t *TeamClient) DropGroupContext(ctx context.Context, group tdproto.JID) error {
+	resp := new(tdapi.Resp)
+
+	if err := t.session.doDelete(ctx, fmt.Sprintf("/api/v4/teams/%s/groups/%s", t.uid, group), resp); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (t *TeamClient) GetChats(f *tdapi.ChatFilter) ([]tdproto.Chat, error) {
+	return t.GetChatsContext(context.Background(), f)
+}
+
+// GetChatsContext loads all chats matching filter, see ChatsIter for lazy loading.
+func (t *TeamClient) GetChatsContext(ctx context.Context, f *tdapi.ChatFilter) ([]tdproto.Chat, error) {
+	var result []tdproto.Chat
+	it := t.ChatsIterContext(ctx, f)
+	for it.Next() {
+		result = append(result, it.Chat())
+	}
+	return result, it.Err()
+}
@@ -0,0 +1,149 @@
+package tdclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tada-team/tdproto"
+	"github.com/tada-team/tdproto/tdapi"
+)
+
+func TestTeamClient(t *testing.T) {
+	srv, s, _ := newTestSession(t)
+
+	t.Run("invalid uid", func(t *testing.T) {
+		if _, err := s.Team("team"); err != InvalidTeamUid {
+			t.Error("want InvalidTeamUid, got:", err)
+		}
+		if _, err := s.AddContact("team", "+79870000000"); err != InvalidTeamUid {
+			t.Error("AddContact: want InvalidTeamUid, got:", err)
+		}
+		if _, err := s.CreateTask("team", tdapi.Task{Description: "x"}); err != InvalidTeamUid {
+			t.Error("CreateTask: want InvalidTeamUid, got:", err)
+		}
+		if _, err := s.GetChats("team", nil); err != InvalidTeamUid {
+			t.Error("GetChats: want InvalidTeamUid, got:", err)
+		}
+		if _, err := s.Ws("team"); err != InvalidTeamUid {
+			t.Error("Ws: want InvalidTeamUid, got:", err)
+		}
+	})
+
+	for _, uid := range []string{srv.Teams()[0], srv.AddTeam("second")} {
+		team, err := s.Team(uid)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		contact, err := team.AddContact("+79870000000")
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+
+		msg, err := team.SendPlaintextMessage(contact.Jid, "hello")
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if msg.Chat != contact.Jid {
+			t.Error("invalid message chat:", msg.Chat)
+		}
+
+		ws, err := team.Ws()
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if _, err := ws.PingContext(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := ws.SendPlainMessageContext(ctx, contact.Jid, "hello"); err != context.Canceled {
+			t.Error("SendPlainMessageContext: want context.Canceled, got:", err)
+		}
+		if err := ws.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTeamLifecycle(t *testing.T) {
+	_, s, _ := newTestSession(t)
+
+	if _, err := s.CreateTeam(tdapi.Team{Name: " "}); err != InvalidTeamName {
+		t.Error("CreateTeam: want InvalidTeamName, got:", err)
+	}
+
+	team, err := s.CreateTeam(tdapi.Team{Name: "project"})
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if team.Name != "project" || team.Me.TeamStatus != tdproto.TeamOwner {
+		t.Error("invalid team:", team)
+	}
+
+	teams, err := s.Teams()
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if len(teams) != 2 || teams[1].Uid != team.Uid {
+		t.Fatal("invalid teams:", teams)
+	}
+
+	t.Run("update", func(t *testing.T) {
+		if _, err := s.RenameTeam(team.Uid, ""); err != InvalidTeamName {
+			t.Error("RenameTeam: want InvalidTeamName, got:", err)
+		}
+
+		renamed, err := s.RenameTeam(team.Uid, "project 2")
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if renamed.Name != "project 2" {
+			t.Error("invalid name:", renamed.Name)
+		}
+
+		age, yes := 3600, true
+		updated, err := s.UpdateTeam(team.Uid, TeamUpdate{MaxMessageUpdateAge: &age, UseTaskUrgency: &yes})
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if updated.Name != "project 2" || updated.MaxMessageUpdateAge != age || !updated.UseTaskUrgency {
+			t.Error("invalid team:", updated)
+		}
+	})
+
+	t.Run("owner can't leave", func(t *testing.T) {
+		if err := s.LeaveTeam(team.Uid); !IsForbidden(err) {
+			t.Error("want forbidden, got:", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if _, err := s.DeleteTeam(team.Uid); err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if _, err := s.GetTeam(team.Uid); !IsNotFound(err) {
+			t.Error("want not found, got:", err)
+		}
+	})
+}
+
+func TestLeaveTeam(t *testing.T) {
+	srv, s, teamUid := newTestSession(t)
+	srv.SetTeamStatus(teamUid, "member")
+
+	contacts, err := s.Contacts(teamUid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RemoveContact(teamUid, contacts[1].Jid); !IsForbidden(err) {
+		t.Error("RemoveContact: member must not remove others, got:", err)
+	}
+
+	if err := s.LeaveTeam(teamUid); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if _, err := s.GetTeam(teamUid); !IsNotFound(err) {
+		t.Error("want not found, got:", err)
+	}
+}
@@ -0,0 +1,165 @@
+package tdclient
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// TokenStore persists session token. Empty token with nil error means no saved token.
+type TokenStore interface {
+	LoadToken() (string, error)
+	SaveToken(token string) error
+	DeleteToken() error
+}
+
+// WithTokenStore makes session load token from store on start and save it on every SetToken.
+func WithTokenStore(store TokenStore) Option {
+	return func(s *Session) error {
+		s.tokenStore = store
+		return nil
+	}
+}
+
+// MemoryTokenStore keeps token in memory, for tests and short-living processes.
+type MemoryTokenStore struct {
+	mu    sync.Mutex
+	token string
+}
+
+func (m *MemoryTokenStore) LoadToken() (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.token, nil
+}
+
+func (m *MemoryTokenStore) SaveToken(token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.token = token
+	return nil
+}
+
+func (m *MemoryTokenStore) DeleteToken() error {
+	return m.SaveToken("")
+}
+
+// FileTokenStore keeps token in file readable by owner only.
+type FileTokenStore struct {
+	path string
+}
+
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+func (f *FileTokenStore) LoadToken() (string, error) {
+	b, err := readTokenFile(f.path)
+	return strings.TrimSpace(string(b)), err
+}
+
+func (f *FileTokenStore) SaveToken(token string) error {
+	return writeFileAtomic(f.path, []byte(token))
+}
+
+func (f *FileTokenStore) DeleteToken() error {
+	return deleteTokenFile(f.path)
+}
+
+// EncryptedFileTokenStore keeps token in file encrypted with AES-GCM.
+type EncryptedFileTokenStore struct {
+	path string
+	aead cipher.AEAD
+}
+
+// NewEncryptedFileTokenStore creates store with AES key of 16, 24 or 32 bytes.
+func NewEncryptedFileTokenStore(path string, key []byte) (*EncryptedFileTokenStore, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid key")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedFileTokenStore{path: path, aead: aead}, nil
+}
+
+func (e *EncryptedFileTokenStore) LoadToken() (string, error) {
+	b, err := readTokenFile(e.path)
+	if err != nil || len(b) == 0 {
+		return "", err
+	}
+
+	nonceSize := e.aead.NonceSize()
+	if len(b) < nonceSize {
+		return "", errors.New("token file corrupted")
+	}
+
+	token, err := e.aead.Open(nil, b[:nonceSize], b[nonceSize:], nil)
+	if err != nil {
+		return "", errors.Wrap(err, "token decrypt fail")
+	}
+
+	return string(token), nil
+}
+
+func (e *EncryptedFileTokenStore) SaveToken(token string) error {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	return writeFileAtomic(e.path, e.aead.Seal(nonce, nonce, []byte(token), nil))
+}
+
+func (e *EncryptedFileTokenStore) DeleteToken() error {
+	return deleteTokenFile(e.path)
+}
+
+func readTokenFile(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return b, err
+}
+
+// writeFileAtomic writes 0600 file via temporary file, so readers never see partial content.
+func writeFileAtomic(path string, b []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	// CreateTemp makes 0600 file, but be explicit
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func deleteTokenFile(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
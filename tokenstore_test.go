@@ -0,0 +1,107 @@
+package tdclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTokenStores(t *testing.T) {
+	dir := t.TempDir()
+
+	encrypted, err := NewEncryptedFileTokenStore(filepath.Join(dir, "encrypted"), []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, store := range map[string]TokenStore{
+		"memory":    new(MemoryTokenStore),
+		"file":      NewFileTokenStore(filepath.Join(dir, "plain")),
+		"encrypted": encrypted,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if token, err := store.LoadToken(); err != nil || token != "" {
+				t.Fatalf("empty store: %q %v", token, err)
+			}
+			if err := store.SaveToken("secret-token"); err != nil {
+				t.Fatal(err)
+			}
+			if token, err := store.LoadToken(); err != nil || token != "secret-token" {
+				t.Fatalf("invalid token: %q %v", token, err)
+			}
+			if err := store.DeleteToken(); err != nil {
+				t.Fatal(err)
+			}
+			if token, err := store.LoadToken(); err != nil || token != "" {
+				t.Fatalf("deleted token: %q %v", token, err)
+			}
+		})
+	}
+
+	t.Run("file permissions and encryption", func(t *testing.T) {
+		path := filepath.Join(dir, "encrypted")
+		if err := encrypted.SaveToken("secret-token"); err != nil {
+			t.Fatal(err)
+		}
+		st, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.Mode().Perm() != 0600 {
+			t.Error("invalid permissions:", st.Mode().Perm())
+		}
+		b, _ := ioutil.ReadFile(path)
+		if strings.Contains(string(b), "secret-token") {
+			t.Error("token not encrypted")
+		}
+	})
+}
+
+func TestReauth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v4/auth/password/get-token":
+			_, _ = w.Write([]byte(`{"ok": true, "result": {"token": "new"}}`))
+		case r.Header.Get("token") != "new":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"ok": false, "error": "InvalidToken"}`))
+		default:
+			_, _ = w.Write([]byte(`{"ok": true, "result": "pong"}`))
+		}
+	}))
+	defer srv.Close()
+
+	store := new(MemoryTokenStore)
+	_ = store.SaveToken("expired")
+
+	var reauthCalls int32
+	s, err := NewSession(srv.URL, WithTokenStore(store), WithReauth(func(ctx context.Context, s *Session) error {
+		atomic.AddInt32(&reauthCalls, 1)
+		auth, err := s.AuthByPasswordGetTokenContext(ctx, "user", "password")
+		if err != nil {
+			return err
+		}
+		s.SetToken(auth.Token)
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if err := s.Ping(); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if n := atomic.LoadInt32(&reauthCalls); n != 1 {
+		t.Error("invalid reauth calls:", n)
+	}
+	if token, _ := store.LoadToken(); token != "new" {
+		t.Error("new token not saved:", token)
+	}
+}
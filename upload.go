@@ -0,0 +1,236 @@
+package tdclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// UploadOption configures single file upload.
+type UploadOption func(c *uploadConfig)
+
+type uploadConfig struct {
+	contentType string
+	size        int64
+	progress    func(sent, total int64)
+}
+
+// UploadContentType sets content type of uploaded file. By default it is guessed by file extension.
+func UploadContentType(v string) UploadOption {
+	return func(c *uploadConfig) {
+		c.contentType = v
+	}
+}
+
+// UploadSize sets known file size, so request is sent with Content-Length instead of chunked encoding.
+// Size of *os.File and readers with Len() method is detected automatically.
+func UploadSize(v int64) UploadOption {
+	return func(c *uploadConfig) {
+		c.size = v
+	}
+}
+
+// UploadProgress sets callback for bytes sent. Total is -1 when file size is unknown.
+func UploadProgress(fn func(sent, total int64)) UploadOption {
+	return func(c *uploadConfig) {
+		c.progress = fn
+	}
+}
+
+func newUploadConfig(fname string, src io.Reader, opts []UploadOption) uploadConfig {
+	c := uploadConfig{size: -1}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if c.contentType == "" {
+		c.contentType = mime.TypeByExtension(filepath.Ext(fname))
+	}
+	if c.contentType == "" {
+		c.contentType = "application/octet-stream"
+	}
+
+	if c.size < 0 {
+		c.size = readerSize(src)
+	}
+
+	return c
+}
+
+func readerSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case *os.File:
+		if st, err := v.Stat(); err == nil && st.Mode().IsRegular() {
+			if pos, err := v.Seek(0, io.SeekCurrent); err == nil {
+				return st.Size() - pos
+			}
+		}
+	case interface{ Len() int }:
+		return int64(v.Len())
+	}
+	return -1
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func uploadPartHeader(fname, contentType string) textproto.MIMEHeader {
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, quoteEscaper.Replace(fname)))
+	h.Set("Content-Type", contentType)
+	return h
+}
+
+// multipartOverhead returns size of multipart envelope around single part.
+func multipartOverhead(boundary string, header textproto.MIMEHeader) (int64, error) {
+	cw := new(countingWriter)
+	w := multipart.NewWriter(cw)
+	if err := w.SetBoundary(boundary); err != nil {
+		return 0, err
+	}
+	if _, err := w.CreatePart(header); err != nil {
+		return 0, err
+	}
+	if err := w.Close(); err != nil {
+		return 0, err
+	}
+	return cw.n, nil
+}
+
+type countingWriter struct{ n int64 }
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+type progressReader struct {
+	r     io.Reader
+	sent  int64
+	total int64
+	fn    func(sent, total int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.sent += int64(n)
+		r.fn(r.sent, r.total)
+	}
+	return n, err
+}
+
+// uploadFile streams src as multipart form without buffering it in memory.
+// Upload is retried only if src implements io.Seeker.
+func (s *Session) uploadFile(ctx context.Context, path string, fname string, src io.ReadCloser, v interface{}, opts ...UploadOption) (http.Header, error) {
+	defer src.Close()
+
+	var u = s.server
+	u.Path = path
+
+	cfg := newUploadConfig(fname, src, opts)
+	partHeader := uploadPartHeader(fname, cfg.contentType)
+
+	seeker, seekable := src.(io.Seeker)
+	var start int64
+	if seekable {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			seekable = false
+		}
+	}
+	if !seekable {
+		ctx = WithIdempotencyKey(ctx, "")
+	}
+
+	// writer goroutine blocks until body is read, but transport or middleware may answer without reading it,
+	// so pipe of every attempt is closed when attempt is over
+	var wg sync.WaitGroup
+	var pr *io.PipeReader
+	release := func() {
+		if pr != nil {
+			pr.Close()
+		}
+		wg.Wait()
+	}
+	defer release()
+
+	attempt := 0
+	resp, respData, err := s.do(ctx, s.uploadTimeout, func(ctx context.Context) (*http.Request, error) {
+		attempt++
+		if attempt > 1 {
+			release()
+			if !seekable {
+				return nil, errors.New("upload body cannot be sent again")
+			}
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+
+		var body io.Reader = src
+		if cfg.progress != nil {
+			body = &progressReader{r: src, total: cfg.size, fn: cfg.progress}
+		}
+
+		var pw *io.PipeWriter
+		pr, pw = io.Pipe()
+		writer := multipart.NewWriter(pw)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), pr)
+		if err != nil {
+			pr.Close()
+			return nil, err
+		}
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+
+		if cfg.size >= 0 {
+			overhead, err := multipartOverhead(writer.Boundary(), partHeader)
+			if err != nil {
+				pr.Close()
+				return nil, err
+			}
+			req.ContentLength = overhead + cfg.size
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pw.CloseWithError(writeUploadPart(writer, partHeader, body))
+		}()
+
+		return req, nil
+	})
+	if err != nil {
+		if resp != nil {
+			return resp.Header, err
+		}
+		return nil, err
+	}
+
+	if err := JSON.Unmarshal(respData, &v); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal fail on: %s", string(respData))
+	}
+
+	return resp.Header, nil
+}
+
+func writeUploadPart(w *multipart.Writer, header textproto.MIMEHeader, src io.Reader) error {
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, src); err != nil {
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,119 @@
+package tdclient
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUploadFile(t *testing.T) {
+	content := strings.Repeat("0123456789", 100000)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength <= int64(len(content)) {
+			t.Error("invalid content length:", r.ContentLength)
+		}
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+
+		if header.Filename != "report.pdf" {
+			t.Error("invalid filename:", header.Filename)
+		}
+		if ct := header.Header.Get("Content-Type"); ct != "application/pdf" {
+			t.Error("invalid content type:", ct)
+		}
+
+		b, _ := ioutil.ReadAll(file)
+		if string(b) != content {
+			t.Error("content mismatched")
+		}
+
+		_, _ = w.Write([]byte(`{"ok": true, "result": {}}`))
+	}))
+	defer srv.Close()
+
+	s, err := NewSession(srv.URL)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	var lastSent, lastTotal int64
+	progress := UploadProgress(func(sent, total int64) {
+		lastSent, lastTotal = sent, total
+	})
+
+	src := ioutil.NopCloser(bytes.NewReader([]byte(content)))
+	if _, err := s.uploadFile(context.Background(), "/upload", "report.pdf", src, nil, progress, UploadSize(int64(len(content)))); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if lastSent != int64(len(content)) || lastTotal != int64(len(content)) {
+		t.Error("invalid progress:", lastSent, lastTotal)
+	}
+}
+
+func TestUploadFileUnreadBody(t *testing.T) {
+	// middleware answers itself and never reads request body
+	cached := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     make(http.Header),
+				Body:       ioutil.NopCloser(strings.NewReader(`{"ok": true, "result": {}}`)),
+				Request:    req,
+			}, nil
+		})
+	}
+
+	s, err := NewSession("http://tada.invalid", WithMiddleware(cached), WithLogger(NopLogger{}))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		src := ioutil.NopCloser(bytes.NewReader(bytes.Repeat([]byte("0"), 1<<20)))
+		_, err := s.uploadFile(context.Background(), "/upload", "big.bin", src, nil)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("upload hangs on unread body")
+	}
+}
+
+func TestMultipartOverhead(t *testing.T) {
+	header := uploadPartHeader(`my "file".txt`, "text/plain")
+	overhead, err := multipartOverhead("boundary", header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cw := new(countingWriter)
+	w := multipart.NewWriter(cw)
+	if err := w.SetBoundary("boundary"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeUploadPart(w, header, strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	if cw.n != overhead+5 {
+		t.Error("overhead mismatched: want:", cw.n-5, "got:", overhead)
+	}
+}
@@ -1,87 +1,67 @@
 package tdclient
 
 import (
-	"fmt"
+	"context"
 	"io"
+	"strings"
 
-	"github.com/google/uuid"
-	"github.com/pkg/errors"
 	"github.com/tada-team/tdproto"
 	"github.com/tada-team/tdproto/tdapi"
 )
 
 func (s *Session) Ping() error {
+	return s.PingContext(context.Background())
+}
+
+func (s *Session) PingContext(ctx context.Context) error {
 	resp := new(struct {
 		tdapi.Resp
 		Result string `json:"result"`
 	})
-	return s.doGet("/api/v4/ping", nil, resp)
+	return s.doGet(ctx, "/api/v4/ping", nil, resp)
 }
 
 func (s *Session) Me(teamUid string) (tdproto.Contact, error) {
-	resp := new(struct {
-		tdapi.Resp
-		Result tdproto.Team `json:"result"`
-	})
-
-	if !tdproto.ValidUid(teamUid) {
-		return tdproto.Contact{}, errors.New("invalid team uid")
-	}
+	return s.MeContext(context.Background(), teamUid)
+}
 
-	if err := s.doGet("/api/v4/teams/"+teamUid, nil, resp); err != nil {
+func (s *Session) MeContext(ctx context.Context, teamUid string) (tdproto.Contact, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
 		return tdproto.Contact{}, err
 	}
-
-	if !resp.Ok {
-		return tdproto.Contact{}, errors.Wrap(resp.Error, "")
-	}
-
-	return resp.Result.Me, nil
+	return team.MeContext(ctx)
 }
 
 func (s *Session) Contacts(teamUid string) ([]tdproto.Contact, error) {
-	resp := new(struct {
-		tdapi.Resp
-		Result []tdproto.Contact `json:"result"`
-	})
-
-	if !tdproto.ValidUid(teamUid) {
-		return resp.Result, errors.New("invalid team uid")
-	}
-
-	if err := s.doGet(fmt.Sprintf("/api/v4/teams/%s/contacts/", teamUid), nil, resp); err != nil {
-		return resp.Result, err
-	}
+	return s.ContactsContext(context.Background(), teamUid)
+}
 
-	if !resp.Ok {
-		return resp.Result, errors.Wrap(resp.Error, "")
+func (s *Session) ContactsContext(ctx context.Context, teamUid string) ([]tdproto.Contact, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return nil, err
 	}
-
-	return resp.Result, nil
+	return team.ContactsContext(ctx)
 }
 
 func (s *Session) AddContact(teamUid string, phone string) (tdproto.Contact, error) {
-	req := map[string]interface{}{
-		"phone": phone,
-	}
-
-	resp := new(struct {
-		tdapi.Resp
-		Result tdproto.Contact `json:"result"`
-	})
-
-	if err := s.doPost(fmt.Sprintf("/api/v4/teams/%s/contacts", teamUid), req, resp); err != nil {
-		return resp.Result, err
-	}
+	return s.AddContactContext(context.Background(), teamUid, phone)
+}
 
-	if !resp.Ok {
-		return resp.Result, errors.Wrap(resp.Error, "")
+func (s *Session) AddContactContext(ctx context.Context, teamUid string, phone string) (tdproto.Contact, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Contact{}, err
 	}
-
-	return resp.Result, nil
+	return team.AddContactContext(ctx, phone)
 }
 
 func (s *Session) AuthBySmsSendCode(phone string) (tdapi.SmsCode, error) {
+	return s.AuthBySmsSendCodeContext(context.Background(), phone)
+}
+
+func (s *Session) AuthBySmsSendCodeContext(ctx context.Context, phone string) (tdapi.SmsCode, error) {
 	req := map[string]interface{}{
 		"phone": phone,
 	}
@@ -91,18 +71,18 @@ func (s *Session) AuthBySmsSendCode(phone string) (tdapi.SmsCode, error) {
 		Result tdapi.SmsCode `json:"result"`
 	})
 
-	if err := s.doPost("/api/v4/auth/sms/send-code", req, resp); err != nil {
+	if err := s.doPost(ctx, "/api/v4/auth/sms/send-code", req, resp); err != nil {
 		return resp.Result, err
 	}
 
-	if !resp.Ok {
-		return resp.Result, errors.Wrap(resp.Error, "")
-	}
-
 	return resp.Result, nil
 }
 
 func (s *Session) AuthBySmsGetToken(phone, code string) (tdapi.Auth, error) {
+	return s.AuthBySmsGetTokenContext(context.Background(), phone, code)
+}
+
+func (s *Session) AuthBySmsGetTokenContext(ctx context.Context, phone, code string) (tdapi.Auth, error) {
 	req := map[string]interface{}{
 		"phone": phone,
 		"code":  code,
@@ -113,18 +93,18 @@ func (s *Session) AuthBySmsGetToken(phone, code string) (tdapi.Auth, error) {
 		Result tdapi.Auth `json:"result"`
 	})
 
-	if err := s.doPost("/api/v4/auth/sms/get-token", req, resp); err != nil {
+	if err := s.doPost(ctx, "/api/v4/auth/sms/get-token", req, resp); err != nil {
 		return resp.Result, err
 	}
 
-	if !resp.Ok {
-		return resp.Result, errors.Wrap(resp.Error, "")
-	}
-
 	return resp.Result, nil
 }
 
 func (s *Session) AuthByPasswordGetToken(username, password string) (tdapi.Auth, error) {
+	return s.AuthByPasswordGetTokenContext(context.Background(), username, password)
+}
+
+func (s *Session) AuthByPasswordGetTokenContext(ctx context.Context, username, password string) (tdapi.Auth, error) {
 	req := map[string]string{
 		"username": username,
 		"password": password,
@@ -135,303 +115,283 @@ func (s *Session) AuthByPasswordGetToken(username, password string) (tdapi.Auth,
 		Result tdapi.Auth `json:"result"`
 	})
 
-	if err := s.doPost("/api/v4/auth/password/get-token", req, resp); err != nil {
+	if err := s.doPost(ctx, "/api/v4/auth/password/get-token", req, resp); err != nil {
 		return resp.Result, err
 	}
 
-	if !resp.Ok {
-		return resp.Result, errors.Wrap(resp.Error, "")
-	}
-
 	return resp.Result, nil
 }
 
-func (s *Session) SendPlaintextMessage(teamUid string, chat tdproto.JID, text string) (tdproto.Message, error) {
-	req := new(tdapi.Message)
-	req.Type = tdproto.MediatypePlain
-	req.Text = text
-
-	req.MessageUid = uuid.New().String()
+func (s *Session) Logout() error {
+	return s.LogoutContext(context.Background())
+}
 
-	resp := new(struct {
-		tdapi.Resp
-		Result tdproto.Message `json:"result"`
-	})
+// LogoutContext revokes session token on server and removes it from session and token store.
+func (s *Session) LogoutContext(ctx context.Context) error {
+	resp := new(tdapi.Resp)
 
-	if err := s.doPost(fmt.Sprintf("/api/v4/teams/%s/chats/%s/messages", teamUid, chat), req, resp); err != nil {
-		return resp.Result, err
+	if err := s.doPost(withoutReauth(ctx), "/api/v4/auth/logout", nil, resp); err != nil && !IsUnauthorized(err) {
+		return err
 	}
 
-	if !resp.Ok {
-		return resp.Result, errors.Wrap(resp.Error, "")
-	}
+	s.SetToken("")
 
-	return resp.Result, nil
+	return nil
 }
 
-func (s *Session) SendUploadMessage(teamUid string, chat tdproto.JID, fname string, file io.ReadCloser) (tdproto.Message, error) {
-	req := new(tdapi.Message)
+func (s *Session) SendPlaintextMessage(teamUid string, chat tdproto.JID, text string) (tdproto.Message, error) {
+	return s.SendPlaintextMessageContext(context.Background(), teamUid, chat, text)
+}
 
-	req.MessageUid = uuid.New().String()
+func (s *Session) SendPlaintextMessageContext(ctx context.Context, teamUid string, chat tdproto.JID, text string) (tdproto.Message, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Message{}, err
+	}
+	return team.SendPlaintextMessageContext(ctx, chat, text)
+}
 
-	resp := new(struct {
-		tdapi.Resp
-		Result tdproto.Message `json:"result"`
-	})
+func (s *Session) SendUploadMessage(teamUid string, chat tdproto.JID, fname string, file io.ReadCloser, opts ...UploadOption) (tdproto.Message, error) {
+	return s.SendUploadMessageContext(context.Background(), teamUid, chat, fname, file, opts...)
+}
 
-	_, err := s.uploadFile(fmt.Sprintf("/api/v4/teams/%s/chats/%s/messages", teamUid, chat), fname, file, resp)
+// SendUploadMessageContext streams file to chat. File is closed after upload.
+func (s *Session) SendUploadMessageContext(ctx context.Context, teamUid string, chat tdproto.JID, fname string, file io.ReadCloser, opts ...UploadOption) (tdproto.Message, error) {
+	team, err := s.Team(teamUid)
 	if err != nil {
-		return tdproto.Message{}, errors.Wrap(err, "uploadFile error")
-	}
-	if !resp.Ok {
-		return resp.Result, errors.Wrap(resp.Error, "")
+		return tdproto.Message{}, err
 	}
-
-	return resp.Result, nil
+	return team.SendUploadMessageContext(ctx, chat, fname, file, opts...)
 }
 
 func (s *Session) GetMessages(teamUid string, chat tdproto.JID, f *tdapi.MessageFilter) ([]tdproto.Message, error) {
-	resp := new(struct {
-		tdapi.Resp
-		Result tdproto.ChatMessages `json:"result"`
-	})
+	return s.GetMessagesContext(context.Background(), teamUid, chat, f)
+}
 
-	if err := s.doGet(fmt.Sprintf("/api/v4/teams/%s/messages/%s", teamUid, chat), f, resp); err != nil {
+func (s *Session) GetMessagesContext(ctx context.Context, teamUid string, chat tdproto.JID, f *tdapi.MessageFilter) ([]tdproto.Message, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
 		return nil, err
 	}
-
-	if !resp.Ok {
-		return nil, errors.Wrap(resp.Error, "")
-	}
-
-	return resp.Result.Messages, nil
+	return team.GetMessagesContext(ctx, chat, f)
 }
 
 func (s *Session) DeleteMessage(teamUid string, chat tdproto.JID, msgId string) (tdproto.ChatMessages, error) {
-	resp := new(struct {
-		tdapi.Resp
-		Result tdproto.ChatMessages `json:"result"`
-	})
-
-	if err := s.doDelete(fmt.Sprintf("/api/v4/teams/%s/chats/%s/messages/%s", teamUid, chat, msgId), resp); err != nil {
-		return resp.Result, err
-	}
+	return s.DeleteMessageContext(context.Background(), teamUid, chat, msgId)
+}
 
-	if !resp.Ok {
-		return resp.Result, errors.Wrap(resp.Error, "")
+func (s *Session) DeleteMessageContext(ctx context.Context, teamUid string, chat tdproto.JID, msgId string) (tdproto.ChatMessages, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.ChatMessages{}, err
 	}
-
-	return resp.Result, nil
+	return team.DeleteMessageContext(ctx, chat, msgId)
 }
 
 func (s *Session) CreateTask(teamUid string, req tdapi.Task) (tdproto.Chat, error) {
-	resp := new(struct {
-		tdapi.Resp
-		Result tdproto.Chat `json:"result"`
-	})
+	return s.CreateTaskContext(context.Background(), teamUid, req)
+}
 
-	if err := s.doPost(fmt.Sprintf("/api/v4/teams/%s/tasks", teamUid), req, resp); err != nil {
-		return resp.Result, err
+func (s *Session) CreateTaskContext(ctx context.Context, teamUid string, req tdapi.Task) (tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Chat{}, err
 	}
+	return team.CreateTaskContext(ctx, req)
+}
+
+func (s *Session) CloseTask(teamUid, taskUid string) (tdproto.Chat, error) {
+	return s.CloseTaskContext(context.Background(), teamUid, taskUid)
+}
 
-	if !resp.Ok {
-		return resp.Result, errors.Wrap(resp.Error, "")
+func (s *Session) CloseTaskContext(ctx context.Context, teamUid string, taskUid string) (tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Chat{}, err
 	}
+	return team.CloseTaskContext(ctx, taskUid)
+}
 
-	return resp.Result, nil
+func (s *Session) Teams() ([]tdproto.Team, error) {
+	return s.TeamsContext(context.Background())
 }
 
-func (s *Session) CloseTask(teamUid, taskUid string) (tdproto.Chat, error) {
+// TeamsContext returns all teams of session account.
+func (s *Session) TeamsContext(ctx context.Context) ([]tdproto.Team, error) {
 	resp := new(struct {
 		tdapi.Resp
-		Result tdproto.Chat `json:"result"`
+		Result []tdproto.Team `json:"result"`
 	})
 
-	req := map[string]interface{}{
-		"task_status": "done",
-	}
-
-	if err := s.doPut(fmt.Sprintf("/api/v4/teams/%s/tasks/%s", teamUid, taskUid), req, resp); err != nil {
+	if err := s.doGet(ctx, "/api/v4/teams", nil, resp); err != nil {
 		return resp.Result, err
 	}
 
-	if !resp.Ok {
-		return resp.Result, errors.Wrap(resp.Error, "")
-	}
-
 	return resp.Result, nil
 }
 
-// FIXME: move to tdapi
-type tdapiTeam struct {
-	Name string `json:"name"`
+func (s *Session) CreateTeam(req tdapi.Team) (tdproto.Team, error) {
+	return s.CreateTeamContext(context.Background(), req)
 }
 
-// FIXME: open
-func (s *Session) createTeam(req tdapiTeam) (tdproto.Team, error) {
+// CreateTeamContext creates team owned by session account.
+func (s *Session) CreateTeamContext(ctx context.Context, req tdapi.Team) (tdproto.Team, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return tdproto.Team{}, InvalidTeamName
+	}
+
 	resp := new(struct {
 		tdapi.Resp
 		Result tdproto.Team `json:"result"`
 	})
 
-	if err := s.doPost("/api/v4/teams", req, resp); err != nil {
+	if err := s.doPost(ctx, "/api/v4/teams", req, resp); err != nil {
 		return resp.Result, err
 	}
 
-	if !resp.Ok {
-		return resp.Result, errors.Wrap(resp.Error, "")
-	}
-
 	return resp.Result, nil
 }
 
-func (s *Session) CreateGroup(teamUid string, req tdapi.Group) (tdproto.Chat, error) {
-	resp := new(struct {
-		tdapi.Resp
-		Result tdproto.Chat `json:"result"`
-	})
-
-	if err := s.doPost(fmt.Sprintf("/api/v4/teams/%s/groups", teamUid), req, resp); err != nil {
-		return resp.Result, err
-	}
+func (s *Session) GetTeam(teamUid string) (tdproto.Team, error) {
+	return s.GetTeamContext(context.Background(), teamUid)
+}
 
-	if !resp.Ok {
-		return resp.Result, errors.Wrap(resp.Error, "")
+func (s *Session) GetTeamContext(ctx context.Context, teamUid string) (tdproto.Team, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Team{}, err
 	}
-
-	return resp.Result, nil
+	return team.GetTeamContext(ctx)
 }
 
-func (s *Session) GetGroups(teamUid string) ([]tdproto.Chat, error) {
-	resp := new(struct {
-		tdapi.Resp
-		Result []tdproto.Chat `json:"result"`
-	})
+func (s *Session) UpdateTeam(teamUid string, req TeamUpdate) (tdproto.Team, error) {
+	return s.UpdateTeamContext(context.Background(), teamUid, req)
+}
 
-	if err := s.doGet(fmt.Sprintf("/api/v4/teams/%s/groups", teamUid), nil, resp); err != nil {
-		return resp.Result, err
+func (s *Session) UpdateTeamContext(ctx context.Context, teamUid string, req TeamUpdate) (tdproto.Team, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Team{}, err
 	}
+	return team.UpdateTeamContext(ctx, req)
+}
+
+func (s *Session) RenameTeam(teamUid, name string) (tdproto.Team, error) {
+	return s.RenameTeamContext(context.Background(), teamUid, name)
+}
 
-	if !resp.Ok {
-		return resp.Result, errors.Wrap(resp.Error, "")
+func (s *Session) RenameTeamContext(ctx context.Context, teamUid, name string) (tdproto.Team, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Team{}, err
 	}
+	return team.RenameTeamContext(ctx, name)
+}
 
-	return resp.Result, nil
+func (s *Session) LeaveTeam(teamUid string) error {
+	return s.LeaveTeamContext(context.Background(), teamUid)
 }
 
-func (s *Session) AddGroupMember(teamUid string, group, contact tdproto.JID) (tdproto.GroupMembership, error) {
-	req := tdproto.GroupMembership{
-		Jid:    contact,
-		Status: tdproto.GroupMember,
+func (s *Session) LeaveTeamContext(ctx context.Context, teamUid string) error {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return err
 	}
+	return team.LeaveTeamContext(ctx)
+}
 
-	resp := new(struct {
-		tdapi.Resp
-		Result tdproto.GroupMembership `json:"result"`
-	})
+func (s *Session) DeleteTeam(teamUid string) (tdproto.Team, error) {
+	return s.DeleteTeamContext(context.Background(), teamUid)
+}
 
-	if err := s.doPost(fmt.Sprintf("/api/v4/teams/%s/groups/%s/members", teamUid, group), req, resp); err != nil {
-		return resp.Result, err
+func (s *Session) DeleteTeamContext(ctx context.Context, teamUid string) (tdproto.Team, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Team{}, err
 	}
+	return team.DeleteTeamContext(ctx)
+}
+
+func (s *Session) CreateGroup(teamUid string, req tdapi.Group) (tdproto.Chat, error) {
+	return s.CreateGroupContext(context.Background(), teamUid, req)
+}
 
-	if !resp.Ok {
-		return resp.Result, errors.Wrap(resp.Error, "")
+func (s *Session) CreateGroupContext(ctx context.Context, teamUid string, req tdapi.Group) (tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.Chat{}, err
 	}
+	return team.CreateGroupContext(ctx, req)
+}
 
-	return resp.Result, nil
+func (s *Session) GetGroups(teamUid string) ([]tdproto.Chat, error) {
+	return s.GetGroupsContext(context.Background(), teamUid)
 }
 
-func (s *Session) GroupMembers(teamUid string, group tdproto.JID) ([]tdproto.GroupMembership, error) {
-	type MembersParams struct {
-		Members []tdproto.GroupMembership `json:"members"`
+func (s *Session) GetGroupsContext(ctx context.Context, teamUid string) ([]tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return nil, err
 	}
-	resp := new(struct {
-		tdapi.Resp
-		Result MembersParams `json:"result"`
-	})
+	return team.GetGroupsContext(ctx)
+}
 
-	if !tdproto.ValidUid(teamUid) {
-		return resp.Result.Members, errors.New("invalid team uid")
-	}
+func (s *Session) AddGroupMember(teamUid string, group, contact tdproto.JID) (tdproto.GroupMembership, error) {
+	return s.AddGroupMemberContext(context.Background(), teamUid, group, contact)
+}
 
-	if err := s.doGet(fmt.Sprintf("/api/v4/teams/%s/groups/%s/members", teamUid, group), nil, resp); err != nil {
-		return resp.Result.Members, err
+func (s *Session) AddGroupMemberContext(ctx context.Context, teamUid string, group, contact tdproto.JID) (tdproto.GroupMembership, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return tdproto.GroupMembership{}, err
 	}
+	return team.AddGroupMemberContext(ctx, group, contact)
+}
 
-	if !resp.Ok {
-		return resp.Result.Members, errors.Wrap(resp.Error, "")
-	}
+func (s *Session) GroupMembers(teamUid string, group tdproto.JID) ([]tdproto.GroupMembership, error) {
+	return s.GroupMembersContext(context.Background(), teamUid, group)
+}
 
-	return resp.Result.Members, nil
+func (s *Session) GroupMembersContext(ctx context.Context, teamUid string, group tdproto.JID) ([]tdproto.GroupMembership, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return nil, err
+	}
+	return team.GroupMembersContext(ctx, group)
 }
 
 func (s *Session) DropGroupMember(teamUid string, group, contact tdproto.JID) error {
-	resp := new(tdapi.Resp)
-
-	if !tdproto.ValidUid(teamUid) {
-		return InvalidTeamUid
-	}
+	return s.DropGroupMemberContext(context.Background(), teamUid, group, contact)
+}
 
-	if err := s.doDelete(fmt.Sprintf("/api/v4/teams/%s/groups/%s/members/%s", teamUid, group, contact), resp); err != nil {
+func (s *Session) DropGroupMemberContext(ctx context.Context, teamUid string, group, contact tdproto.JID) error {
+	team, err := s.Team(teamUid)
+	if err != nil {
 		return err
 	}
-
-	if !resp.Ok {
-		return errors.Wrap(resp.Error, "")
-	}
-
-	return nil
+	return team.DropGroupMemberContext(ctx, group, contact)
 }
 
 func (s *Session) DropGroup(teamUid string, group tdproto.JID) error {
-	resp := new(tdapi.Resp)
-
-	if !tdproto.ValidUid(teamUid) {
-		return InvalidTeamUid
-	}
+	return s.DropGroupContext(context.Background(), teamUid, group)
+}
 
-	if err := s.doDelete(fmt.Sprintf("/api/v4/teams/%s/groups/%s", teamUid, group), resp); err != nil {
+func (s *Session) DropGroupContext(ctx context.Context, teamUid string, group tdproto.JID) error {
+	team, err := s.Team(teamUid)
+	if err != nil {
 		return err
 	}
-
-	if !resp.Ok {
-		return errors.Wrap(resp.Error, "")
-	}
-
-	return nil
+	return team.DropGroupContext(ctx, group)
 }
 
 func (s *Session) GetChats(teamUid string, f *tdapi.ChatFilter) ([]tdproto.Chat, error) {
-	resp := new(struct {
-		tdapi.Resp
-		Result tdproto.PaginatedChats `json:"result"`
-	})
-
-	if f == nil {
-		f = new(tdapi.ChatFilter)
-	}
-
-	if f.Limit == 0 {
-		f.Limit = 100
-	}
-
-	var result []tdproto.Chat
-	for {
-		if err := s.doGet(fmt.Sprintf("/api/v4/teams/%s/chats", teamUid), f, resp); err != nil {
-			return result, err
-		}
-
-		if !resp.Ok {
-			return result, errors.Wrap(resp.Error, "")
-		}
-
-		if len(resp.Result.Objects) == 0 {
-			break
-		}
+	return s.GetChatsContext(context.Background(), teamUid, f)
+}
 
-		f.Offset += f.Limit
-		result = append(result, resp.Result.Objects...)
+func (s *Session) GetChatsContext(ctx context.Context, teamUid string, f *tdapi.ChatFilter) ([]tdproto.Chat, error) {
+	team, err := s.Team(teamUid)
+	if err != nil {
+		return nil, err
 	}
-
-	return result, nil
+	return team.GetChatsContext(ctx, f)
 }
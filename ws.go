@@ -1,6 +1,7 @@
 package tdclient
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -19,17 +20,36 @@ var (
 )
 
 func (s *Session) Ws(team string) (*WsSession, error) {
-	if s.token == "" {
+	return s.WsContext(context.Background(), team)
+}
+
+// WsContext opens websocket connection for the team. Context is used for dialing only.
+func (s *Session) WsContext(ctx context.Context, team string) (*WsSession, error) {
+	t, err := s.Team(team)
+	if err != nil {
+		return nil, err
+	}
+	return t.WsContext(ctx)
+}
+
+func (t *TeamClient) Ws() (*WsSession, error) {
+	return t.WsContext(context.Background())
+}
+
+// WsContext opens websocket connection for the team. Context is used for dialing only.
+func (t *TeamClient) WsContext(ctx context.Context) (*WsSession, error) {
+	if !t.session.hasCredentials() {
 		return nil, errors.New("empty token")
 	}
 
 	w := &WsSession{
-		session:        s,
-		team:           team,
+		session:        t.session,
+		logger:         t.session.logger,
+		team:           t.uid,
 		eventListeners: make([]eventListener, 0),
 	}
 
-	return w, w.Start()
+	return w, w.StartContext(ctx)
 }
 
 type serverEvent struct {
@@ -42,28 +62,69 @@ type eventListener struct {
 	finishedChannel chan struct{}
 }
 
+// wsConn is the part of *websocket.Conn used by WsSession.
+type wsConn interface {
+	ReadMessage() (messageType int, p []byte, err error)
+	WriteMessage(messageType int, data []byte) error
+	SetWriteDeadline(t time.Time) error
+	CloseHandler() func(code int, text string) error
+}
+
 type WsSession struct {
 	session             *Session
 	currentError        error
 	eventListeners      []eventListener
 	eventListenerMutext sync.Mutex
 	team                string
-	websocket           *websocket.Conn
+	websocket           wsConn
 	sendMutex           sync.Mutex
+	logger              Logger
+	loggerMutex         sync.RWMutex
+}
+
+// SetLogger sets websocket logger. Default is session logger.
+func (w *WsSession) SetLogger(l Logger) {
+	w.loggerMutex.Lock()
+	defer w.loggerMutex.Unlock()
+	w.logger = l
+}
+
+func (w *WsSession) log() Logger {
+	w.loggerMutex.RLock()
+	defer w.loggerMutex.RUnlock()
+	return w.logger
 }
 
 func (w *WsSession) Start() error {
+	return w.StartContext(context.Background())
+}
+
+func (w *WsSession) StartContext(ctx context.Context) error {
 	if len(w.eventListeners) > 0 {
 		return fmt.Errorf("event listeners exist, cannot restart socket")
 	}
 
 	u := w.session.server
 	u.Path = "/messaging/" + w.team
-	u.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
-	conn, _, err := websocket.DefaultDialer.Dial(u.String(), http.Header{
-		"token": []string{w.session.token},
-	})
 
+	header := make(http.Header)
+	w.session.authorizeHeader(&u, header)
+
+	dial := func() (*websocket.Conn, error) {
+		wsUrl := u
+		wsUrl.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
+		conn, resp, err := w.session.wsDialer.DialContext(ctx, wsUrl.String(), header)
+		w.session.storeCookies(&u, resp)
+		return conn, err
+	}
+
+	var conn wsConn
+	var err error
+	if w.session.cassette != nil {
+		conn, err = w.session.cassette.wsConn(u.Path, dial)
+	} else {
+		conn, err = dial()
+	}
 	if err != nil {
 		return err
 	}
@@ -76,14 +137,23 @@ func (w *WsSession) Start() error {
 }
 
 func (w *WsSession) Ping() string {
-	confirmId := tdproto.ConfirmId()
-	w.SendRaw(tdproto.XClientPing(confirmId))
+	confirmId, _ := w.PingContext(context.Background())
 	return confirmId
 }
 
+func (w *WsSession) PingContext(ctx context.Context) (string, error) {
+	confirmId := tdproto.ConfirmId()
+	return confirmId, w.SendRawContext(ctx, tdproto.XClientPing(confirmId))
+}
+
 func (w *WsSession) SendPlainMessage(to tdproto.JID, text string) string {
+	uid, _ := w.SendPlainMessageContext(context.Background(), to, text)
+	return uid
+}
+
+func (w *WsSession) SendPlainMessageContext(ctx context.Context, to tdproto.JID, text string) (string, error) {
 	uid := uuid.New().String()
-	w.SendEvent(tdproto.NewClientMessageUpdated(tdproto.ClientMessageUpdatedParams{
+	return uid, w.SendEventContext(ctx, tdproto.NewClientMessageUpdated(tdproto.ClientMessageUpdatedParams{
 		MessageId: uid,
 		To:        to,
 		Content: tdproto.MessageContent{
@@ -91,25 +161,36 @@ func (w *WsSession) SendPlainMessage(to tdproto.JID, text string) string {
 			Text: text,
 		},
 	}))
-	return uid
 }
 
 func (w *WsSession) DeleteMessage(uid string) error {
-	return w.SendEvent(tdproto.NewClientMessageDeleted(uid))
+	return w.DeleteMessageContext(context.Background(), uid)
+}
+
+func (w *WsSession) DeleteMessageContext(ctx context.Context, uid string) error {
+	return w.SendEventContext(ctx, tdproto.NewClientMessageDeleted(uid))
 }
 
 func (w *WsSession) WaitForMessage() (tdproto.Message, bool, error) {
+	return w.WaitForMessageContext(context.Background())
+}
+
+func (w *WsSession) WaitForMessageContext(ctx context.Context) (tdproto.Message, bool, error) {
 	v := new(tdproto.ServerMessageUpdated)
-	if err := w.WaitFor(v); err != nil {
+	if err := w.WaitForContext(ctx, v); err != nil {
 		return tdproto.Message{}, false, err
 	}
 	return v.Params.Messages[0], v.Params.Delayed, nil
 }
 
 func (w *WsSession) WaitForConfirm() (string, error) {
+	return w.WaitForConfirmContext(context.Background())
+}
+
+func (w *WsSession) WaitForConfirmContext(ctx context.Context) (string, error) {
 	v := getServerConfirm()
 	defer releaseServerConfirm(v)
-	if err := w.WaitFor(v); err != nil {
+	if err := w.WaitForContext(ctx, v); err != nil {
 		return "", err
 	}
 	return v.Params.ConfirmId, nil
@@ -136,6 +217,11 @@ func (w *WsSession) removeLisener(listenerData *eventListener) {
 }
 
 func (w *WsSession) WaitFor(v tdproto.Event) error {
+	return w.WaitForContext(context.Background(), v)
+}
+
+// WaitForContext waits for the event with same name as v. Context cancellation is returned as ctx.Err().
+func (w *WsSession) WaitForContext(ctx context.Context, v tdproto.Event) error {
 	name := v.GetName()
 
 	listener, err := w.createListener(name)
@@ -144,13 +230,20 @@ func (w *WsSession) WaitFor(v tdproto.Event) error {
 	}
 	defer w.removeLisener(listener)
 
+	var timeout <-chan time.Time
+	if w.session.wsWaitTimeout > 0 {
+		timer := time.NewTimer(w.session.wsWaitTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
 	for {
 		select {
 		case ev, ok := <-(*listener).eventChannel:
 			if !ok {
 				return w.currentError
 			}
-			tdclientGlgLogger.Debug("recieved event: ", string(ev.raw))
+			w.log().Debug("received event", "data", w.session.redactor.json(ev.raw))
 			switch ev.name {
 			case name:
 				if err := JSON.Unmarshal(ev.raw, &v); err != nil {
@@ -158,24 +251,43 @@ func (w *WsSession) WaitFor(v tdproto.Event) error {
 				}
 				return nil
 			}
-		case <-time.After(httpClient.Timeout):
+		case <-timeout:
 			return Timeout
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 }
 
 func (w *WsSession) SendRaw(b []byte) error {
+	return w.SendRawContext(context.Background(), b)
+}
+
+// SendRawContext writes raw frame. Context deadline shortens write deadline, if set.
+func (w *WsSession) SendRawContext(ctx context.Context, b []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	w.sendMutex.Lock()
 	defer w.sendMutex.Unlock()
 
-	err := w.websocket.SetWriteDeadline(time.Now().Add(httpClient.Timeout))
+	var deadline time.Time
+	if w.session.wsWriteTimeout > 0 {
+		deadline = time.Now().Add(w.session.wsWriteTimeout)
+	}
+	if ctxDeadline, ok := ctx.Deadline(); ok && (deadline.IsZero() || ctxDeadline.Before(deadline)) {
+		deadline = ctxDeadline
+	}
+
+	err := w.websocket.SetWriteDeadline(deadline)
 	if err != nil {
 		return err
 	}
 
-	tdclientGlgLogger.Debug("raw sent:", string(b))
+	w.log().Debug("raw sent", "data", w.session.redactor.json(b))
 	if err := w.websocket.WriteMessage(websocket.BinaryMessage, b); err != nil {
-		tdclientGlgLogger.Warn(errors.Wrap(err, ""))
+		w.log().Warn("websocket write fail", "error", err)
 		return err
 	}
 
@@ -198,13 +310,17 @@ func (w *WsSession) Close() error {
 }
 
 func (w *WsSession) SendEvent(event tdproto.Event) error {
+	return w.SendEventContext(context.Background(), event)
+}
+
+func (w *WsSession) SendEventContext(ctx context.Context, event tdproto.Event) error {
 	b, err := JSON.Marshal(event)
 	if err != nil {
-		tdclientGlgLogger.Warn(errors.Wrap(err, ""))
+		w.log().Warn("event marshal fail", "error", err)
 		return err
 	}
-	tdclientGlgLogger.Info("sending event:", event)
-	return w.SendRaw(b)
+	w.log().Info("sending event", "event", event.GetName())
+	return w.SendRawContext(ctx, b)
 }
 
 func (w *WsSession) inboxLoop() {
@@ -214,20 +330,20 @@ func (w *WsSession) inboxLoop() {
 		if err != nil {
 			defer w.StopListeners()
 			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
-				tdclientGlgLogger.Info("closing websocket read loop")
+				w.log().Info("closing websocket read loop")
 				return
 			}
-			tdclientGlgLogger.Error("websocket reading error: ", err)
+			w.log().Error("websocket reading error", "error", err)
 			w.currentError = err
 			return
 		}
 
-		tdclientGlgLogger.Debugf("received websocket data %q", data)
+		w.log().Debug("received websocket data", "data", w.session.redactor.json(data))
 
 		var receivedEvent map[string]interface{}
 		err = json.Unmarshal(data, &receivedEvent)
 		if err != nil {
-			tdclientGlgLogger.Warn("failed to unmarshal json event: ", err)
+			w.log().Warn("failed to unmarshal json event", "error", err)
 			continue
 		}
 
@@ -242,12 +358,12 @@ func (w *WsSession) inboxLoop() {
 		eventNameInterface := receivedEvent["event"]
 		eventName, ok := eventNameInterface.(string)
 		if !ok {
-			tdclientGlgLogger.Warn("failed to get event name of event, got: ", eventNameInterface)
+			w.log().Warn("failed to get event name of event", "event", eventNameInterface)
 			continue
 		}
 
 		if eventName == "server.warning" {
-			tdclientGlgLogger.Warnf("recieved server warning: %q", receivedEvent["params"])
+			w.log().Warn("received server warning", "params", receivedEvent["params"])
 		}
 
 		ev := serverEvent{
@@ -280,24 +396,36 @@ func (w *WsSession) inboxLoop() {
 }
 
 func (w *WsSession) SendCallOffer(jid tdproto.JID, sdp string) {
+	w.SendCallOfferContext(context.Background(), jid, sdp)
+}
+
+func (w *WsSession) SendCallOfferContext(ctx context.Context, jid tdproto.JID, sdp string) error {
 	callOffer := new(tdproto.ClientCallOffer)
 	callOffer.Name = callOffer.GetName()
 	callOffer.Params.Jid = jid
 	callOffer.Params.Trickle = false
 	callOffer.Params.Sdp = sdp
-	w.SendEvent(callOffer)
+	return w.SendEventContext(ctx, callOffer)
 }
 
 func (w *WsSession) SendCallLeave(jid tdproto.JID) {
+	w.SendCallLeaveContext(context.Background(), jid)
+}
+
+func (w *WsSession) SendCallLeaveContext(ctx context.Context, jid tdproto.JID) error {
 	callLeave := new(tdproto.ClientCallLeave)
 	callLeave.Name = callLeave.GetName()
 	callLeave.Params.Jid = jid
 	callLeave.Params.Reason = ""
-	w.SendEvent(callLeave)
+	return w.SendEventContext(ctx, callLeave)
 }
 
 func (w *WsSession) ForeachMessage(messageHandler func(chan tdproto.Message, chan error)) error {
+	return w.ForeachMessageContext(context.Background(), messageHandler)
+}
 
+// ForeachMessageContext feeds received messages to handler until handler reports to error channel or context is done.
+func (w *WsSession) ForeachMessageContext(ctx context.Context, messageHandler func(chan tdproto.Message, chan error)) error {
 	eventName := tdproto.ServerMessageUpdated{}.GetName()
 
 	listener, err := w.createListener(eventName)
@@ -320,7 +448,7 @@ func (w *WsSession) ForeachMessage(messageHandler func(chan tdproto.Message, cha
 				return w.currentError
 			}
 
-			tdclientGlgLogger.Debug("recieved event: ", string(ev.raw))
+			w.log().Debug("received event", "data", w.session.redactor.json(ev.raw))
 			switch ev.name {
 			case eventName:
 				if err := JSON.Unmarshal(ev.raw, &event); err != nil {
@@ -335,16 +463,23 @@ func (w *WsSession) ForeachMessage(messageHandler func(chan tdproto.Message, cha
 					{
 
 					}
+				case <-ctx.Done():
+					return ctx.Err()
 				}
 			}
 		case err := <-errorsChan:
 			return err
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 }
 
 func (w *WsSession) ForeachData(eventName string, interfaceHandler func(chan []byte, chan error)) error {
+	return w.ForeachDataContext(context.Background(), eventName, interfaceHandler)
+}
 
+func (w *WsSession) ForeachDataContext(ctx context.Context, eventName string, interfaceHandler func(chan []byte, chan error)) error {
 	listener, err := w.createListener("")
 	if err != nil {
 		return err
@@ -367,7 +502,7 @@ func (w *WsSession) ForeachData(eventName string, interfaceHandler func(chan []b
 				continue
 			}
 
-			tdclientGlgLogger.Debug("recieved event: ", string(ev.raw))
+			w.log().Debug("received event", "data", w.session.redactor.json(ev.raw))
 
 			select {
 			case err := <-errorsChan:
@@ -378,10 +513,14 @@ func (w *WsSession) ForeachData(eventName string, interfaceHandler func(chan []b
 				{
 
 				}
+			case <-ctx.Done():
+				return ctx.Err()
 			}
 
 		case err := <-errorsChan:
 			return err
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 }